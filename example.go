@@ -0,0 +1,129 @@
+package tribo
+
+import (
+	"embed"
+	"flag"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+// exampleDir is the directory of the example blog embedded in the binary.
+const exampleDir = "example"
+
+// exampleBlog contains the example blog which is written out by "tribo init".
+// The config file is listed separately as files starting with a dot aren't
+// embedded when a directory is given.
+//go:embed example example/.tribo.yaml
+var exampleBlog embed.FS
+
+// runInit creates a new blog in a directory from the example blog.
+func runInit(args []string) {
+	flags := flag.NewFlagSet(os.Args[0]+" init", flag.ExitOnError)
+	blogName := flags.String("blogName", "", "blog name")
+	blogDescription := flags.String("blogDescription", "", "blog description")
+	baseUrlPath := flags.String("baseUrlPath", "", "base blog URL path")
+	rssLinkUrl := flags.String("rssLinkUrl", "", "base URL used for absolute links in feeds and the sitemap")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		log.Fatalf("Usage: %v init [options] <dir>", os.Args[0])
+	}
+	dir := flags.Arg(0)
+
+	configValues := yaml.MapSlice{
+		{Key: "blogName", Value: *blogName},
+		{Key: "blogDescription", Value: *blogDescription},
+		{Key: "baseUrlPath", Value: *baseUrlPath},
+		{Key: "rssLinkUrl", Value: *rssLinkUrl},
+	}
+
+	err := writeExampleBlog(dir, configValues)
+	if err != nil {
+		log.Fatalf("Failed to create blog in '%v': "+err.Error(), dir)
+	}
+
+	log.Infof("Created new blog in '%v'", dir)
+}
+
+// writeExampleBlog writes the example blog into a directory.
+// The directory must not exist or be empty.
+// Any non-empty config values given are set in the config file of the new blog.
+func writeExampleBlog(dir string, configValues yaml.MapSlice) error {
+	fileList, err := ioutil.ReadDir(dir)
+	if err == nil && len(fileList) > 0 {
+		return fmt.Errorf("Directory isn't empty")
+	}
+
+	err = fs.WalkDir(exampleBlog, exampleDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(exampleDir, filepath.FromSlash(path))
+		if err != nil {
+			return err
+		}
+		outputPath := filepath.Join(dir, relPath)
+
+		if entry.IsDir() {
+			return os.MkdirAll(outputPath, 0775)
+		}
+
+		data, err := exampleBlog.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		return ioutil.WriteFile(outputPath, data, 0664)
+	})
+	if err != nil {
+		return err
+	}
+
+	return updateExampleConfig(filepath.Join(dir, ".tribo.yaml"), configValues)
+}
+
+// updateExampleConfig sets values in the config file of a new blog.
+// Values which are empty are left as they are in the example config.
+func updateExampleConfig(configFile string, configValues yaml.MapSlice) error {
+	configYAML, err := ioutil.ReadFile(configFile)
+	if err != nil {
+		return err
+	}
+
+	var exampleConfig yaml.MapSlice
+	err = yaml.Unmarshal(configYAML, &exampleConfig)
+	if err != nil {
+		return fmt.Errorf("Error parsing YAML file '%v': "+err.Error(), configFile)
+	}
+
+	for _, value := range configValues {
+		if value.Value == "" {
+			continue
+		}
+
+		found := false
+		for i := range exampleConfig {
+			if exampleConfig[i].Key == value.Key {
+				exampleConfig[i].Value = value.Value
+				found = true
+			}
+		}
+		if !found {
+			exampleConfig = append(exampleConfig, value)
+		}
+	}
+
+	configYAML, err = yaml.Marshal(exampleConfig)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(configFile, append([]byte("---\n"), configYAML...), 0664)
+}
@@ -1,14 +1,20 @@
 document.addEventListener("DOMContentLoaded", function() {
     if (document.getElementById("post-list")) {
-        const blogList = new List('post-list', {
+        const listOptions = {
             valueNames: [
                 'title',
                 'tag',
                 {data: ['id'] }
-            ],
-            page: 10,
-            pagination: true
-        });
+            ]
+        };
+
+        // Only page the list in the browser if it isn't already split into pages by Tribo
+        if (document.querySelector('#post-list .pagination')) {
+            listOptions.page = 10;
+            listOptions.pagination = true;
+        }
+
+        const blogList = new List('post-list', listOptions);
 
         tagClear = document.getElementById('tag-search-clear');
 
@@ -0,0 +1,53 @@
+package tribo
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestWriteExampleBlog(t *testing.T) {
+	assert := assert.New(t)
+	dir := filepath.Join(t.TempDir(), "blog")
+
+	configValues := yaml.MapSlice{
+		{Key: "blogName", Value: "Test Blog"},
+		{Key: "blogDescription", Value: ""},
+		{Key: "baseUrlPath", Value: "/blog"},
+		{Key: "rssLinkUrl", Value: "https://example.com"},
+	}
+
+	err := writeExampleBlog(dir, configValues)
+	if err != nil {
+		t.Fatalf("Failed to write example blog: %v", err.Error())
+	}
+
+	assert.FileExists(filepath.Join(dir, "templates", "post.html.tmpl"), "Missing post template")
+	assert.FileExists(filepath.Join(dir, "templates", "includes", "header.html.tmpl"), "Missing header template")
+	assert.FileExists(filepath.Join(dir, "static", "blog.css"), "Missing static file")
+	assert.FileExists(filepath.Join(dir, "posts", "2021", "03", "image-post", "resources", "cat.jpg"), "Missing post resource")
+
+	configYAML, err := ioutil.ReadFile(filepath.Join(dir, ".tribo.yaml"))
+	if err != nil {
+		t.Fatalf("Failed to read config file: %v", err.Error())
+	}
+
+	config := make(map[string]string)
+	err = yaml.Unmarshal(configYAML, &config)
+	if err != nil {
+		t.Fatalf("Failed to parse config file: %v", err.Error())
+	}
+	assert.Equal("Test Blog", config["blogName"], "Incorrect blog name in config")
+	assert.Equal("/blog", config["baseUrlPath"], "Incorrect base URL path in config")
+	assert.Equal("https://example.com", config["rssLinkUrl"], "Incorrect RSS link URL in config")
+	_, exists := config["blogDescription"]
+	assert.False(exists, "Empty config value shouldn't be set")
+	_, exists = config["outputDir"]
+	assert.False(exists, "Output directory shouldn't be set in the example config")
+
+	err = writeExampleBlog(dir, configValues)
+	assert.Error(err, "Expected error writing blog to a non-empty directory")
+}
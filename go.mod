@@ -1,12 +1,15 @@
 module github.com/cswilson90/tribo
 
-go 1.15
+go 1.16
 
 require (
+	github.com/BurntSushi/toml v0.3.1
+	github.com/alecthomas/chroma v0.10.0
+	github.com/fsnotify/fsnotify v1.4.9
 	github.com/gomarkdown/markdown v0.0.0-20201113031856-722100d81a8e
 	github.com/otiai10/copy v1.4.2
 	github.com/sirupsen/logrus v1.7.0
 	github.com/stretchr/objx v0.3.0 // indirect
-	github.com/stretchr/testify v1.3.0
+	github.com/stretchr/testify v1.7.0
 	gopkg.in/yaml.v2 v2.4.0
 )
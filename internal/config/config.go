@@ -20,10 +20,13 @@ package config
 
 import (
 	"flag"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v2"
@@ -55,11 +58,69 @@ type TriboConfig struct {
 		Can be ignored if NoRss is set to true.
 	*/
 	RssLinkUrl string `yaml:"rssLinkUrl"`
+	// RssTtl is the number of minutes a feed reader should cache the RSS feed for.
+	RssTtl int `yaml:"rssTtl"`
+	// RssFullContent controls whether the full content of each post is added to the RSS feed.
+	// The content is added in a content:encoded element as well as the preview being used as
+	// the description.
+	RssFullContent bool `yaml:"rssFullContent"`
+	// FeedItems is the max number of posts included in the RSS, Atom and JSON feeds.
+	FeedItems int `yaml:"feedItems"`
+	// TagRss controls whether an RSS feed is generated for each tag.
+	// Tag feeds aren't generated if NoRss is set.
+	TagRss bool `yaml:"tagRss"`
+
+	// NoAtom controls whether an Atom feed is generated.
+	// The default value is false so an Atom feed will be generated.
+	NoAtom bool `yaml:"noAtom"`
+	// AuthorName and AuthorEmail are used as the author of the blog in the Atom feed.
+	// If AuthorName isn't set the BlogName is used instead.
+	AuthorName  string `yaml:"authorName"`
+	AuthorEmail string `yaml:"authorEmail"`
+	// NoJsonFeed controls whether a JSON Feed is generated.
+	// The default value is false so a JSON Feed will be generated.
+	NoJsonFeed bool `yaml:"noJsonFeed"`
+
+	// NoSitemap controls whether a sitemap is generated.
+	// The default value is false so a sitemap will be generated.
+	NoSitemap bool `yaml:"noSitemap"`
+	// RobotsDisallow is a list of URL paths added as disallow rules in the generated robots.txt.
+	// A robots.txt isn't generated if there is one in the static directory.
+	RobotsDisallow []string `yaml:"robotsDisallow"`
 
 	OutputDir   string `yaml:"outputDir"`
 	PostsDir    string `yaml:"postsDir"`
 	StaticDir   string `yaml:"staticDir"`
 	TemplateDir string `yaml:"templateDir"`
+	// PagesDir is the directory containing standalone pages such as an about page.
+	// Pages are output to a path mirroring their location in the directory.
+	PagesDir string `yaml:"pagesDir"`
+	// Theme is a directory containing a "templates" and a "static" directory which are used
+	// as the base for the blog. Files in TemplateDir and StaticDir replace theme files with
+	// the same name.
+	Theme string `yaml:"theme"`
+
+	// PostsPerPage is the number of posts on each page of the post list.
+	// If it's not set all posts are listed on a single page.
+	PostsPerPage int `yaml:"postsPerPage"`
+
+	// NoHighlight controls whether fenced code blocks are syntax highlighted.
+	// The default value is false so code blocks with a language are highlighted.
+	NoHighlight bool `yaml:"noHighlight"`
+	// HighlightStyle is the name of the Chroma style used to colour highlighted code.
+	HighlightStyle string `yaml:"highlightStyle"`
+	// HighlightClasses controls whether highlighted code uses CSS classes instead of inline
+	// styles. When it's set a stylesheet for the HighlightStyle is saved in the output.
+	HighlightClasses bool `yaml:"highlightClasses"`
+
+	// Toc controls whether a table of contents is generated for posts.
+	// It can be turned on or off for a single post with the toc metadata key.
+	Toc bool `yaml:"toc"`
+	// HeadingAnchors controls whether a link to the heading is added to each heading in a post.
+	HeadingAnchors bool `yaml:"headingAnchors"`
+	// Markdown controls the markdown extensions and HTML output used for all posts.
+	// Posts can override the options with the markdown metadata key.
+	Markdown MarkdownConfig `yaml:"markdown"`
 
 	// Parallelism controls the max number of blog posts built in parallel.
 	// Defaults to the number of CPUs available on the machine.
@@ -67,11 +128,46 @@ type TriboConfig struct {
 	// FuturePosts controls whether blog posts with a publish date set in the future
 	// are published.
 	FuturePosts bool `yaml:"futurePosts"`
+	// Drafts controls whether blog posts marked as a draft in their metadata are published.
+	Drafts bool `yaml:"drafts"`
 	// NoOutputCleanup controls whether Tribo tries to clean up old blog posts in the output.
 	// By default Tribo will delete any directories from the output directory that it thinks are
 	// from posts which no longer exist or have been moved due to a title or published date change.
 	// You can set this option to true to stop this behaviour if it is causing problems.
 	NoOutputCleanup bool `yaml:"noOutputCleanup"`
+	// FullRebuild forces every post to be rebuilt even if its inputs haven't changed.
+	// By default Tribo keeps a build manifest in the output directory and skips posts
+	// whose content, the templates and the config are the same as the last build.
+	FullRebuild bool `yaml:"fullRebuild"`
+}
+
+/*
+	MarkdownConfig stores the options for parsing markdown and rendering it as HTML.
+
+	The extension options turn on parts of the markdown syntax which aren't in the original
+	markdown and the other options change the HTML output.
+*/
+type MarkdownConfig struct {
+	// Tables, Strikethrough, Autolink, DefinitionLists, MathBlocks and HeadingIds are
+	// enabled by default.
+	Tables          bool `yaml:"tables"`
+	Strikethrough   bool `yaml:"strikethrough"`
+	Autolink        bool `yaml:"autolink"`
+	DefinitionLists bool `yaml:"definitionLists"`
+	MathBlocks      bool `yaml:"mathBlocks"`
+	// HeadingIds allows the id of a heading to be given with the {#id} syntax.
+	HeadingIds     bool `yaml:"headingIds"`
+	Footnotes      bool `yaml:"footnotes"`
+	Superscript    bool `yaml:"superscript"`
+	HardLineBreaks bool `yaml:"hardLineBreaks"`
+
+	// Smartypants converts quotes, dashes and fractions into their typographic forms.
+	// It's enabled by default.
+	Smartypants         bool `yaml:"smartypants"`
+	HrefTargetBlank     bool `yaml:"hrefTargetBlank"`
+	NofollowLinks       bool `yaml:"nofollowLinks"`
+	SkipHtml            bool `yaml:"skipHtml"`
+	FootnoteReturnLinks bool `yaml:"footnoteReturnLinks"`
 }
 
 var (
@@ -86,21 +182,42 @@ var (
 	*/
 	Values TriboConfig
 
+	// ConfigFile is the absolute path of the config file that Values was loaded from.
+	// The file may not exist if no config file was given on the command line.
+	ConfigFile string
+
 	// defaultConfig defines the default values for the config.
 	defaultConfig = TriboConfig{
 		BlogName:        "My Blog",
 		BlogDescription: "My musings about the world",
 
 		RssLinkUrl: "http://127.0.0.1",
+		RssTtl:     1800,
+		FeedItems:  10,
 
 		OutputDir:   "blog",
 		PostsDir:    "posts",
+		PagesDir:    "pages",
 		StaticDir:   "static",
 		TemplateDir: "templates",
 
+		HighlightStyle: "github",
+
+		Markdown: MarkdownConfig{
+			Tables:          true,
+			Strikethrough:   true,
+			Autolink:        true,
+			DefinitionLists: true,
+			MathBlocks:      true,
+			HeadingIds:      true,
+			Smartypants:     true,
+		},
+
 		Parallelism:     runtime.NumCPU(),
 		FuturePosts:     false,
+		Drafts:          false,
 		NoOutputCleanup: false,
+		FullRebuild:     false,
 	}
 )
 
@@ -110,12 +227,30 @@ var (
 // The command line arguments (minus the program name) should be given as an argument to
 // this function.
 func Init(cmdArgs []string) {
+	InitFlags(flag.NewFlagSet(os.Args[0], flag.ContinueOnError), cmdArgs)
+}
+
+// InitFlags works the same as Init but parses the command line using the given FlagSet.
+// This allows sub-commands to define their own flags in addition to the config flags.
+// Any arguments left after parsing the flags are available from flags.Args().
+// The program exits if the config file can't be loaded.
+func InitFlags(flags *flag.FlagSet, cmdArgs []string) {
+	err := LoadFlags(flags, cmdArgs)
+	if err != nil {
+		log.Fatalf(err.Error())
+	}
+}
+
+// LoadFlags works the same as InitFlags but returns an error instead of exiting if the
+// config file can't be loaded. Values isn't changed if there's an error so the last good
+// config can still be used.
+func LoadFlags(flags *flag.FlagSet, cmdArgs []string) error {
 	log.SetLevel(log.DebugLevel)
 
+	lastValues := Values
 	Values = defaultConfig
 
 	// Set up and parse flags
-	flags := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
 	configFile := flags.String("configFile", defaultConfigFile, "config file")
 
 	blogName := flags.String("blogName", "", "blog name")
@@ -124,19 +259,45 @@ func Init(cmdArgs []string) {
 
 	noRss := flags.Bool("noRss", false, "don't generate an RSS feed")
 	rssLinkUrl := flags.String("rssLinkUrl", "", "RSS link base URL")
+	rssTtl := flags.Int("rssTtl", 0, "RSS feed TTL in minutes")
+	rssFullContent := flags.Bool("rssFullContent", false, "add the full content of posts to the RSS feed")
+	feedItems := flags.Int("feedItems", 0, "max number of posts in the feeds")
+	tagRss := flags.Bool("tagRss", false, "generate an RSS feed for each tag")
+	noAtom := flags.Bool("noAtom", false, "don't generate an Atom feed")
+	authorName := flags.String("authorName", "", "author name")
+	authorEmail := flags.String("authorEmail", "", "author email address")
+	noJsonFeed := flags.Bool("noJsonFeed", false, "don't generate a JSON Feed")
+	noSitemap := flags.Bool("noSitemap", false, "don't generate a sitemap")
+	robotsDisallow := flags.String("robotsDisallow", "", "comma separated list of paths to disallow in robots.txt")
 
 	outputDir := flags.String("outputDir", "", "output directory")
 	postsDir := flags.String("postsDir", "", "posts directory")
+	pagesDir := flags.String("pagesDir", "", "pages directory")
 	staticDir := flags.String("staticDir", "", "static files directory")
 	templateDir := flags.String("templateDir", "", "template directory")
+	theme := flags.String("theme", "", "theme directory")
+
+	postsPerPage := flags.Int("postsPerPage", 0, "number of posts on each page of the post list")
+
+	noHighlight := flags.Bool("noHighlight", false, "don't syntax highlight code blocks")
+	highlightStyle := flags.String("highlightStyle", "", "syntax highlighting style")
+	highlightClasses := flags.Bool("highlightClasses", false, "use CSS classes for syntax highlighting")
+	toc := flags.Bool("toc", false, "generate a table of contents for posts")
+	headingAnchors := flags.Bool("headingAnchors", false, "add links to the headings in posts")
 
 	parallelism := flags.Int("parallelism", 0, "max parallelism")
 	futurePosts := flags.Bool("futurePosts", false, "publish future posts")
+	drafts := flags.Bool("drafts", false, "publish draft posts")
 	noOutputCleanup := flags.Bool("noOutputCleanup", false, "don't attempt to clean up output directory")
+	fullRebuild := flags.Bool("fullRebuild", false, "rebuild all posts even if they haven't changed")
 	flags.Parse(cmdArgs)
 
 	// Load values from config file into Values
-	loadConfigFile(*configFile)
+	err := loadConfigFile(*configFile)
+	if err != nil {
+		Values = lastValues
+		return err
+	}
 
 	// Overwrite values in Values with those from command line if they've been given
 	if *blogName != "" {
@@ -154,33 +315,124 @@ func Init(cmdArgs []string) {
 	if *rssLinkUrl != "" {
 		Values.RssLinkUrl = *rssLinkUrl
 	}
+	if *rssTtl != 0 {
+		Values.RssTtl = *rssTtl
+	}
+	if *rssFullContent {
+		Values.RssFullContent = *rssFullContent
+	}
+	if *feedItems != 0 {
+		Values.FeedItems = *feedItems
+	}
+	if *tagRss {
+		Values.TagRss = *tagRss
+	}
+	if *noAtom {
+		Values.NoAtom = *noAtom
+	}
+	if *authorName != "" {
+		Values.AuthorName = *authorName
+	}
+	if *authorEmail != "" {
+		Values.AuthorEmail = *authorEmail
+	}
+	if *noJsonFeed {
+		Values.NoJsonFeed = *noJsonFeed
+	}
+	if *noSitemap {
+		Values.NoSitemap = *noSitemap
+	}
+	if *robotsDisallow != "" {
+		Values.RobotsDisallow = strings.Split(*robotsDisallow, ",")
+	}
 	if *outputDir != "" {
 		Values.OutputDir = *outputDir
 	}
 	if *postsDir != "" {
 		Values.PostsDir = *postsDir
 	}
+	if *pagesDir != "" {
+		Values.PagesDir = *pagesDir
+	}
 	if *staticDir != "" {
 		Values.StaticDir = *staticDir
 	}
 	if *templateDir != "" {
 		Values.TemplateDir = *templateDir
 	}
+	if *theme != "" {
+		Values.Theme = *theme
+	}
+	if *postsPerPage != 0 {
+		Values.PostsPerPage = *postsPerPage
+	}
+	if *noHighlight {
+		Values.NoHighlight = *noHighlight
+	}
+	if *highlightStyle != "" {
+		Values.HighlightStyle = *highlightStyle
+	}
+	if *highlightClasses {
+		Values.HighlightClasses = *highlightClasses
+	}
+	if *toc {
+		Values.Toc = *toc
+	}
+	if *headingAnchors {
+		Values.HeadingAnchors = *headingAnchors
+	}
 	if *parallelism != 0 {
 		Values.Parallelism = *parallelism
 	}
 	if *futurePosts {
 		Values.FuturePosts = *futurePosts
 	}
+	if *drafts {
+		Values.Drafts = *drafts
+	}
 	if *noOutputCleanup {
 		Values.NoOutputCleanup = *noOutputCleanup
 	}
+	if *fullRebuild {
+		Values.FullRebuild = *fullRebuild
+	}
 
 	// Convert file/path arguments into absolute paths
 	Values.OutputDir = absPath(Values.OutputDir)
 	Values.PostsDir = absPath(Values.PostsDir)
+	Values.PagesDir = absPath(Values.PagesDir)
 	Values.StaticDir = absPath(Values.StaticDir)
 	Values.TemplateDir = absPath(Values.TemplateDir)
+	if Values.Theme != "" {
+		Values.Theme = absPath(Values.Theme)
+	}
+
+	return nil
+}
+
+// Override returns a copy of the markdown config with some of the options replaced.
+// Option names are the same as in the config file but are matched case insensitively.
+// An error is returned if an option doesn't exist.
+func (m MarkdownConfig) Override(options map[string]bool) (MarkdownConfig, error) {
+	configValue := reflect.ValueOf(&m).Elem()
+	configType := configValue.Type()
+
+	for name, enabled := range options {
+		found := false
+		for i := 0; i < configType.NumField(); i++ {
+			if strings.EqualFold(configType.Field(i).Tag.Get("yaml"), name) {
+				configValue.Field(i).SetBool(enabled)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return m, fmt.Errorf("Unknown markdown option '%v'", name)
+		}
+	}
+
+	return m, nil
 }
 
 // absPath converts a file path to an absolute path.
@@ -195,30 +447,34 @@ func absPath(file string) string {
 }
 
 // loadConfigFile loads the config from a file into the Values variable.
-func loadConfigFile(configFile string) {
+// An error is returned if the file can't be read or parsed, or if a config file other than the
+// default is given and it doesn't exist.
+func loadConfigFile(configFile string) error {
 	configFileGiven := configFile != defaultConfigFile
 
 	absConfig, err := filepath.Abs(configFile)
 	if err != nil {
-		log.Fatalf("Failed to get absolute path of '%v'", configFile)
+		return fmt.Errorf("Failed to get absolute path of '%v'", configFile)
 	}
+	ConfigFile = absConfig
 
 	// Check if config file exists
 	if _, err := os.Stat(absConfig); os.IsNotExist(err) {
 		if configFileGiven {
-			log.Fatalf("Config file '%v' doesn't exist", absConfig)
+			return fmt.Errorf("Config file '%v' doesn't exist", absConfig)
 		}
-		return
+		return nil
 	}
 
 	configYAML, err := ioutil.ReadFile(absConfig)
 	if err != nil {
-		log.Errorf(err.Error())
-		return
+		return fmt.Errorf("Failed to read config file '%v': "+err.Error(), absConfig)
 	}
 
 	err = yaml.Unmarshal(configYAML, &Values)
 	if err != nil {
-		log.Errorf("Error parsing YAML file '%v': "+err.Error(), absConfig)
+		return fmt.Errorf("Error parsing YAML file '%v': "+err.Error(), absConfig)
 	}
+
+	return nil
 }
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"flag"
 	"fmt"
 	"runtime"
 	"testing"
@@ -8,6 +9,16 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+var defaultMarkdown = MarkdownConfig{
+	Tables:          true,
+	Strikethrough:   true,
+	Autolink:        true,
+	DefinitionLists: true,
+	MathBlocks:      true,
+	HeadingIds:      true,
+	Smartypants:     true,
+}
+
 var tests = []struct {
 	flags          []string
 	expectedValues TriboConfig
@@ -19,10 +30,15 @@ var tests = []struct {
 			BlogDescription: "My musings about the world",
 			NoRss:           false,
 			RssLinkUrl:      "http://127.0.0.1",
+			RssTtl:          1800,
+			FeedItems:       10,
 			OutputDir:       "blog",
 			PostsDir:        "posts",
+			PagesDir:        "pages",
 			StaticDir:       "static",
 			TemplateDir:     "templates",
+			HighlightStyle:  "github",
+			Markdown:        defaultMarkdown,
 			Parallelism:     runtime.NumCPU(),
 			FuturePosts:     false,
 			NoOutputCleanup: false,
@@ -33,22 +49,48 @@ var tests = []struct {
 			"-outputDir", "/home/test/output",
 			"-postsDir", "other/posts",
 			"-parallelism", "8",
+			"-postsPerPage", "20",
 			"-futurePosts",
+			"-drafts",
 			"-rssLinkUrl", "https://example.com",
+			"-rssTtl", "60",
+			"-rssFullContent",
+			"-feedItems", "5",
+			"-noSitemap",
+			"-robotsDisallow", "/private/,/drafts/",
 			"-noOutputCleanup",
+			"-theme", "themes/corporate",
+			"-highlightStyle", "monokai",
+			"-highlightClasses",
+			"-toc",
+			"-headingAnchors",
 		},
 		expectedValues: TriboConfig{
-			BlogName:        "My Blog",
-			BlogDescription: "My musings about the world",
-			NoRss:           false,
-			RssLinkUrl:      "https://example.com",
-			OutputDir:       "/home/test/output",
-			PostsDir:        "other/posts",
-			StaticDir:       "static",
-			TemplateDir:     "templates",
-			Parallelism:     8,
-			FuturePosts:     true,
-			NoOutputCleanup: true,
+			BlogName:         "My Blog",
+			BlogDescription:  "My musings about the world",
+			NoRss:            false,
+			RssLinkUrl:       "https://example.com",
+			RssTtl:           60,
+			RssFullContent:   true,
+			FeedItems:        5,
+			NoSitemap:        true,
+			RobotsDisallow:   []string{"/private/", "/drafts/"},
+			OutputDir:        "/home/test/output",
+			PostsDir:         "other/posts",
+			PagesDir:         "pages",
+			StaticDir:        "static",
+			TemplateDir:      "templates",
+			Theme:            "themes/corporate",
+			PostsPerPage:     20,
+			HighlightStyle:   "monokai",
+			HighlightClasses: true,
+			Toc:              true,
+			HeadingAnchors:   true,
+			Markdown:         defaultMarkdown,
+			Parallelism:      8,
+			FuturePosts:      true,
+			Drafts:           true,
+			NoOutputCleanup:  true,
 		},
 	},
 	{
@@ -58,10 +100,26 @@ var tests = []struct {
 			BlogDescription: "A blog for my test",
 			NoRss:           true,
 			RssLinkUrl:      "http://127.0.0.1",
+			RssTtl:          1800,
+			FeedItems:       10,
+			RobotsDisallow:  []string{"/search/"},
 			OutputDir:       "/home/test/output",
 			PostsDir:        "posts",
+			PagesDir:        "pages",
 			StaticDir:       "static",
 			TemplateDir:     "other/templates",
+			NoHighlight:     true,
+			HighlightStyle:  "github",
+			Markdown: MarkdownConfig{
+				Tables:          true,
+				Strikethrough:   true,
+				Autolink:        true,
+				DefinitionLists: true,
+				MathBlocks:      true,
+				HeadingIds:      true,
+				Footnotes:       true,
+				HrefTargetBlank: true,
+			},
 			Parallelism:     runtime.NumCPU(),
 			FuturePosts:     true,
 			NoOutputCleanup: false,
@@ -78,9 +136,58 @@ func TestConfig(t *testing.T) {
 		expected := tc.expectedValues
 		expected.OutputDir = absPath(expected.OutputDir)
 		expected.PostsDir = absPath(expected.PostsDir)
+		expected.PagesDir = absPath(expected.PagesDir)
 		expected.StaticDir = absPath(expected.StaticDir)
 		expected.TemplateDir = absPath(expected.TemplateDir)
+		if expected.Theme != "" {
+			expected.Theme = absPath(expected.Theme)
+		}
 
 		assert.Equal(expected, Values, fmt.Sprintf("Test %v unexpected result", i+1))
 	}
 }
+
+func TestLoadFlagsMissingFile(t *testing.T) {
+	assert := assert.New(t)
+
+	Init([]string{"-blogName", "Last Good Blog"})
+	lastValues := Values
+
+	err := LoadFlags(flag.NewFlagSet("test", flag.ContinueOnError), []string{"-configFile", "testdata/missing.yaml"})
+	assert.NotNil(err, "No error for missing config file")
+	assert.Equal(lastValues, Values, "Config changed after failing to load")
+}
+
+func TestMarkdownOverride(t *testing.T) {
+	assert := assert.New(t)
+
+	mdConfig, err := defaultMarkdown.Override(map[string]bool{"footnotes": true, "SMARTYPANTS": false})
+	if assert.Nil(err, "Unexpected error overriding markdown config") {
+		expected := defaultMarkdown
+		expected.Footnotes = true
+		expected.Smartypants = false
+		assert.Equal(expected, mdConfig, "Incorrect overridden markdown config")
+	}
+
+	mdConfig, err = defaultMarkdown.Override(nil)
+	assert.Nil(err, "Unexpected error overriding markdown config with no options")
+	assert.Equal(defaultMarkdown, mdConfig, "Markdown config changed with no options")
+
+	_, err = defaultMarkdown.Override(map[string]bool{"emoji": true})
+	assert.NotNil(err, "No error for unknown markdown option")
+}
+
+func TestLoadFlagsInvalidFile(t *testing.T) {
+	assert := assert.New(t)
+
+	err := LoadFlags(flag.NewFlagSet("test", flag.ContinueOnError), []string{"-configFile", "testdata/test_config.yaml"})
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err.Error())
+	}
+	lastValues := Values
+
+	err = LoadFlags(flag.NewFlagSet("test", flag.ContinueOnError), []string{"-configFile", "testdata/invalid_config.yaml"})
+	assert.NotNil(err, "No error for invalid config file")
+	assert.Equal(lastValues, Values, "Config changed after failing to parse")
+	assert.Equal("Test Blog", Values.BlogName, "Last good config not kept")
+}
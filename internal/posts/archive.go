@@ -0,0 +1,269 @@
+package posts
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/cswilson90/tribo/internal/config"
+)
+
+// archiveDirName is the name of the directory in the output which contains the archive of all posts.
+const archiveDirName = "archive"
+
+// archiveDirs keeps track of the year and month directories given an archive page in the
+// current build so archive pages for periods which no longer have posts can be removed.
+var archiveDirs = make(DirSet)
+
+// archiveData contains the template data for an archive page.
+type archiveData struct {
+	// Title describes the period of the archive e.g. "2021" or "March 2021".
+	// It's "Archive" for the archive of all posts.
+	Title string
+	// Year and Month are the period of the archive.
+	// Month is 0 for a year archive and both are 0 for the archive of all posts.
+	Year  int
+	Month int
+	Url   string
+	// Posts is the list of posts published in the period sorted by publish date.
+	Posts []postData
+	// Years groups the posts by year, newest first, for the archive of all posts.
+	// For a year archive it contains just that year.
+	Years []archiveYearData
+	// PrevUrl and PrevTitle link to the previous period with posts.
+	// NextUrl and NextTitle link to the next period with posts.
+	// They are empty if there isn't an earlier or later period or for the archive of all posts.
+	PrevUrl   string
+	PrevTitle string
+	NextUrl   string
+	NextTitle string
+}
+
+// archiveYearData contains the template data for the posts published in a year.
+type archiveYearData struct {
+	Year  int
+	Url   string
+	Posts []postData
+	// Months lists the months of the year with posts, newest first.
+	Months []archiveMonthData
+}
+
+// archiveMonthData contains the template data for the posts published in a month.
+type archiveMonthData struct {
+	// Title is the name of the month e.g. "March 2021".
+	Title string
+	Month int
+	Url   string
+	Posts []postData
+}
+
+// archivePageData contains all the template data for rendering an archive page.
+type archivePageData struct {
+	Common  commonData
+	Archive archiveData
+}
+
+// archivePeriod stores the posts published in a year or month.
+type archivePeriod struct {
+	year  int
+	month time.Month
+	posts Posts
+}
+
+// archiveOutput generates an archive page for each year and month with posts and a page
+// for the archive of all posts.
+// Year archives are saved in "YYYY/index.html", month archives in "YYYY/MM/index.html" and
+// the archive of all posts in "archive/index.html". They all use the "archive.html.tmpl" template.
+// The posts should be sorted by date published.
+func archiveOutput(posts Posts, outputDir string) error {
+	archiveDirs = make(DirSet)
+
+	years, months := groupPostsByPeriod(posts)
+
+	for i, year := range years {
+		tmplData := archivePageData{
+			Common: comData(),
+			Archive: archiveData{
+				Title: year.title(),
+				Year:  year.year,
+				Url:   year.url(),
+				Posts: postsData(year.posts),
+				Years: []archiveYearData{year.data(months)},
+			},
+		}
+		tmplData.Common.PageTitle = year.title() + " - " + config.Values.BlogName
+		setAdjacentPeriods(&tmplData.Archive, years, i)
+
+		err := year.render(outputDir, tmplData)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, month := range months {
+		tmplData := archivePageData{
+			Common: comData(),
+			Archive: archiveData{
+				Title: month.title(),
+				Year:  month.year,
+				Month: int(month.month),
+				Url:   month.url(),
+				Posts: postsData(month.posts),
+			},
+		}
+		tmplData.Common.PageTitle = month.title() + " - " + config.Values.BlogName
+		setAdjacentPeriods(&tmplData.Archive, months, i)
+
+		err := month.render(outputDir, tmplData)
+		if err != nil {
+			return err
+		}
+	}
+
+	tmplData := archivePageData{
+		Common: comData(),
+		Archive: archiveData{
+			Title: "Archive",
+			Url:   config.Values.BaseUrlPath + "/" + archiveDirName + "/",
+			Posts: postsData(posts),
+			Years: make([]archiveYearData, len(years)),
+		},
+	}
+	tmplData.Common.PageTitle = "Archive - " + config.Values.BlogName
+	for i, year := range years {
+		tmplData.Archive.Years[i] = year.data(months)
+	}
+
+	archiveDir := filepath.Join(outputDir, archiveDirName)
+	err := os.MkdirAll(archiveDir, 0775)
+	if err != nil {
+		return err
+	}
+
+	err = renderTemplate("archive.html.tmpl", filepath.Join(archiveDir, "index.html"), tmplData)
+	if err != nil {
+		return err
+	}
+	addListPage(tmplData.Archive.Url, posts)
+
+	return nil
+}
+
+// groupPostsByPeriod groups posts by the year and the month they were published.
+// The years and months are ordered newest first.
+// The posts should be sorted by date published.
+func groupPostsByPeriod(posts Posts) (years, months []*archivePeriod) {
+	years = make([]*archivePeriod, 0)
+	months = make([]*archivePeriod, 0)
+
+	for _, post := range posts {
+		year, month, _ := post.metadata.publishDate.Date()
+
+		if len(years) == 0 || years[len(years)-1].year != year {
+			years = append(years, &archivePeriod{year: year, posts: make(Posts, 0)})
+		}
+		years[len(years)-1].posts = append(years[len(years)-1].posts, post)
+
+		lastMonth := len(months) - 1
+		if lastMonth < 0 || months[lastMonth].year != year || months[lastMonth].month != month {
+			months = append(months, &archivePeriod{year: year, month: month, posts: make(Posts, 0)})
+		}
+		months[len(months)-1].posts = append(months[len(months)-1].posts, post)
+	}
+
+	return years, months
+}
+
+// setAdjacentPeriods sets the links to the previous and next periods of an archive.
+// The periods should be ordered newest first.
+func setAdjacentPeriods(archive *archiveData, periods []*archivePeriod, i int) {
+	if i+1 < len(periods) {
+		archive.PrevUrl = periods[i+1].url()
+		archive.PrevTitle = periods[i+1].title()
+	}
+	if i > 0 {
+		archive.NextUrl = periods[i-1].url()
+		archive.NextTitle = periods[i-1].title()
+	}
+}
+
+// render outputs the archive page of a period using the "archive.html.tmpl" template.
+func (a *archivePeriod) render(outputDir string, tmplData archivePageData) error {
+	archiveDir := filepath.Join(outputDir, a.dir())
+	err := os.MkdirAll(archiveDir, 0775)
+	if err != nil {
+		return err
+	}
+	archiveDirs[archiveDir] = true
+
+	err = renderTemplate("archive.html.tmpl", filepath.Join(archiveDir, "index.html"), tmplData)
+	if err != nil {
+		return err
+	}
+	addListPage(a.url(), a.posts)
+
+	return nil
+}
+
+// data returns the template data for a year.
+// The months should be all the months with posts ordered newest first.
+func (a *archivePeriod) data(months []*archivePeriod) archiveYearData {
+	yearData := archiveYearData{
+		Year:   a.year,
+		Url:    a.url(),
+		Posts:  postsData(a.posts),
+		Months: make([]archiveMonthData, 0),
+	}
+
+	for _, month := range months {
+		if month.year == a.year {
+			yearData.Months = append(yearData.Months, archiveMonthData{
+				Title: month.title(),
+				Month: int(month.month),
+				Url:   month.url(),
+				Posts: postsData(month.posts),
+			})
+		}
+	}
+
+	return yearData
+}
+
+// dir returns the output directory of the period relative to the root of the output.
+func (a *archivePeriod) dir() string {
+	if a.month == 0 {
+		return strconv.Itoa(a.year)
+	}
+
+	return filepath.Join(strconv.Itoa(a.year), a.monthString())
+}
+
+// url returns the URL path of the archive page of the period.
+func (a *archivePeriod) url() string {
+	return config.Values.BaseUrlPath + "/" + filepath.ToSlash(a.dir()) + "/"
+}
+
+// title returns the name of the period e.g. "2021" or "March 2021".
+func (a *archivePeriod) title() string {
+	if a.month == 0 {
+		return strconv.Itoa(a.year)
+	}
+
+	return a.month.String() + " " + strconv.Itoa(a.year)
+}
+
+// monthString returns the month of the period as a two digit number as used in post paths.
+func (a *archivePeriod) monthString() string {
+	return time.Date(a.year, a.month, 1, 0, 0, 0, 0, time.UTC).Format("01")
+}
+
+// postsData returns the template data for a list of posts with preview content.
+func postsData(posts Posts) []postData {
+	data := make([]postData, len(posts))
+	for i, post := range posts {
+		data[i] = postToPostData(post, true)
+	}
+
+	return data
+}
@@ -0,0 +1,99 @@
+package posts
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cswilson90/tribo/internal/config"
+)
+
+func TestGroupPostsByPeriod(t *testing.T) {
+	config.Init([]string{})
+	config.Values.BaseUrlPath = baseUrlPath
+
+	posts := Posts{
+		&Post{metadata: &PostMetadata{publishDate: time.Date(2021, time.March, 2, 0, 0, 0, 0, time.UTC)}},
+		&Post{metadata: &PostMetadata{publishDate: time.Date(2021, time.January, 24, 0, 0, 0, 0, time.UTC)}},
+		&Post{metadata: &PostMetadata{publishDate: time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)}},
+		&Post{metadata: &PostMetadata{publishDate: time.Date(2020, time.December, 4, 0, 0, 0, 0, time.UTC)}},
+	}
+
+	years, months := groupPostsByPeriod(posts)
+
+	assert := assert.New(t)
+	if assert.Equal(2, len(years), "Incorrect number of years") {
+		assert.Equal("2021", years[0].title(), "Incorrect title for year 1")
+		assert.Equal(baseUrlPath+"/2021/", years[0].url(), "Incorrect URL for year 1")
+		assert.Equal(3, len(years[0].posts), "Incorrect number of posts in year 1")
+		assert.Equal(1, len(years[1].posts), "Incorrect number of posts in year 2")
+	}
+
+	if assert.Equal(3, len(months), "Incorrect number of months") {
+		assert.Equal("March 2021", months[0].title(), "Incorrect title for month 1")
+		assert.Equal(baseUrlPath+"/2021/01/", months[1].url(), "Incorrect URL for month 2")
+		assert.Equal(2, len(months[1].posts), "Incorrect number of posts in month 2")
+		assert.Equal(baseUrlPath+"/2020/12/", months[2].url(), "Incorrect URL for month 3")
+	}
+
+	archive := archiveData{}
+	setAdjacentPeriods(&archive, months, 1)
+	assert.Equal(baseUrlPath+"/2020/12/", archive.PrevUrl, "Incorrect previous period URL")
+	assert.Equal("December 2020", archive.PrevTitle, "Incorrect previous period title")
+	assert.Equal(baseUrlPath+"/2021/03/", archive.NextUrl, "Incorrect next period URL")
+	assert.Equal("March 2021", archive.NextTitle, "Incorrect next period title")
+
+	archive = archiveData{}
+	setAdjacentPeriods(&archive, months, 0)
+	assert.Empty(archive.NextUrl, "Newest period shouldn't have a next period")
+}
+
+func TestArchiveOutput(t *testing.T) {
+	config.Init([]string{})
+	log.SetLevel(log.FatalLevel)
+	config.Values.StaticDir = staticDir
+	config.Values.TemplateDir = templateDir
+
+	tmpDir := t.TempDir()
+
+	// Add an archive page for a year without posts which should be removed automatically
+	oldArchiveDir := filepath.Join(tmpDir, "2019", "05")
+	os.MkdirAll(oldArchiveDir, 0775)
+	ioutil.WriteFile(filepath.Join(oldArchiveDir, "index.html"), []byte("old archive"), 0664)
+
+	err := BuildPosts(inputDir, tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to build posts: %v", err.Error())
+	}
+
+	assert := assert.New(t)
+	assert.False(fileExists(filepath.Join(tmpDir, "2019")), "Old archive directory hasn't been removed")
+
+	expectedContent := map[string][]string{
+		"2021/index.html":    {"January 2021", "March 2021", "April 2021", `href="/2020/"`},
+		"2021/03/index.html": {"2021 03 Front Matter", `href="/2021/01/"`, `href="/2021/04/"`},
+		"2020/12/index.html": {"2020 12 Post 2", `href="/2021/01/"`},
+		"archive/index.html": {`href="/2021/"`, `href="/2020/"`, "December 2020", "Single Post"},
+	}
+	for file, expected := range expectedContent {
+		html, err := ioutil.ReadFile(filepath.Join(tmpDir, file))
+		if err != nil {
+			t.Errorf("Expected archive file '%v' doesn't exist", file)
+			continue
+		}
+
+		for _, content := range expected {
+			assert.Contains(string(html), content, "Missing content in '%v'", file)
+		}
+	}
+
+	html, err := ioutil.ReadFile(filepath.Join(tmpDir, "2021/03/index.html"))
+	if err == nil {
+		assert.NotContains(string(html), "2021 01 Post 1", "Post from another month in month archive")
+	}
+}
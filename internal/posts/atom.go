@@ -0,0 +1,153 @@
+package posts
+
+import (
+	"encoding/xml"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/cswilson90/tribo/internal/config"
+)
+
+// AtomDateFormat is the format used to output dates in the Atom feed
+const AtomDateFormat = time.RFC3339
+
+// AtomXML describes the top level format used to encode the data into XML for the Atom feed.
+// The feed corresponds to the whole blog.
+// See the Atom specification (RFC 4287) for more information.
+type AtomXML struct {
+	XMLName  xml.Name        `xml:"http://www.w3.org/2005/Atom feed"`
+	Title    string          `xml:"title"`
+	Subtitle string          `xml:"subtitle,omitempty"`
+	Id       string          `xml:"id"`
+	Updated  string          `xml:"updated"`
+	Links    []*AtomLinkXML  `xml:"link"`
+	Author   *AtomPersonXML  `xml:"author"`
+	Entries  []*AtomEntryXML `xml:"entry"`
+}
+
+// AtomEntryXML describes the structure of the XML for a single entry in the Atom feed.
+// An entry in the Atom feed corresponds to a single blog post.
+// See the Atom specification (RFC 4287) for more information.
+type AtomEntryXML struct {
+	XMLName    xml.Name           `xml:"entry"`
+	Title      string             `xml:"title"`
+	Id         string             `xml:"id"`
+	Updated    string             `xml:"updated"`
+	Published  string             `xml:"published"`
+	Links      []*AtomLinkXML     `xml:"link"`
+	Categories []*AtomCategoryXML `xml:"category"`
+	Summary    *AtomTextXML       `xml:"summary"`
+	Content    *AtomTextXML       `xml:"content"`
+}
+
+// AtomLinkXML describes the structure of the XML for a link in the Atom feed.
+type AtomLinkXML struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+// AtomPersonXML describes the structure of the XML for the author of the Atom feed.
+type AtomPersonXML struct {
+	Name  string `xml:"name"`
+	Email string `xml:"email,omitempty"`
+	Uri   string `xml:"uri,omitempty"`
+}
+
+// AtomCategoryXML describes the structure of the XML for a category of an Atom entry.
+// Each tag of a post is added as a category.
+type AtomCategoryXML struct {
+	Term string `xml:"term,attr"`
+}
+
+// AtomTextXML describes the structure of the XML for a text construct in the Atom feed.
+// Type should be "text" or "html". HTML content is escaped when encoded.
+type AtomTextXML struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// postAtomFeed outputs the Atom feed for the blog.
+// The Atom feed is saved in "atom.xml" in the root directory of the blog.
+// The posts should be sorted by date published.
+func postAtomFeed(posts Posts, outputFile string) {
+	if config.Values.NoAtom {
+		log.Infof("Not generating Atom file as it's disabled in the config")
+		return
+	}
+
+	log.Infof("Writing Atom XML to '%v'", outputFile)
+
+	// Add newest posts to Atom feed
+	maxPosts := feedMaxPosts(posts)
+
+	// The feed was last updated when the most recently updated post was
+	lastUpdated := time.Now()
+	if maxPosts > 0 {
+		lastUpdated = posts[0].metadata.lastUpdated()
+	}
+
+	entriesXML := make([]*AtomEntryXML, maxPosts)
+	for i := 0; i < maxPosts; i++ {
+		post := posts[i]
+
+		// The link of a post is used as its ID so the ID stays the same between builds
+		postLink := absoluteUrl(post.urlPath)
+
+		categories := make([]*AtomCategoryXML, len(post.metadata.tags))
+		for j, tag := range post.metadata.tags {
+			categories[j] = &AtomCategoryXML{Term: tag}
+		}
+
+		updated := post.metadata.lastUpdated()
+		if updated.After(lastUpdated) {
+			lastUpdated = updated
+		}
+
+		entriesXML[i] = &AtomEntryXML{
+			Title:      post.title,
+			Id:         postLink,
+			Updated:    updated.Format(AtomDateFormat),
+			Published:  post.metadata.publishDate.Format(AtomDateFormat),
+			Links:      []*AtomLinkXML{{Href: postLink, Rel: "alternate", Type: "text/html"}},
+			Categories: categories,
+			Summary:    &AtomTextXML{Type: "html", Body: post.preview},
+			Content:    &AtomTextXML{Type: "html", Body: post.content},
+		}
+	}
+
+	blogLink := absoluteUrl(config.Values.BaseUrlPath + "/")
+	atomXML := &AtomXML{
+		Title:    config.Values.BlogName,
+		Subtitle: config.Values.BlogDescription,
+		Id:       blogLink,
+		Updated:  lastUpdated.Format(AtomDateFormat),
+		Links: []*AtomLinkXML{
+			{Href: blogLink, Rel: "alternate", Type: "text/html"},
+			{Href: absoluteUrl(config.Values.BaseUrlPath + "/atom.xml"), Rel: "self", Type: "application/atom+xml"},
+		},
+		Author:  feedAuthor(),
+		Entries: entriesXML,
+	}
+
+	err := writeXMLFile(outputFile, atomXML)
+	if err != nil {
+		log.Errorf("Failed to write Atom file: " + err.Error())
+	}
+}
+
+// feedAuthor returns the author of the blog for the Atom feed.
+// If no author name has been configured the name of the blog is used.
+func feedAuthor() *AtomPersonXML {
+	name := config.Values.AuthorName
+	if name == "" {
+		name = config.Values.BlogName
+	}
+
+	return &AtomPersonXML{
+		Name:  name,
+		Email: config.Values.AuthorEmail,
+		Uri:   absoluteUrl(config.Values.BaseUrlPath + "/"),
+	}
+}
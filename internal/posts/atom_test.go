@@ -0,0 +1,91 @@
+package posts
+
+import (
+	"bufio"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cswilson90/tribo/internal/config"
+)
+
+func TestAtom(t *testing.T) {
+	config.Init([]string{})
+	config.Values.BlogName = blogName
+	config.Values.BlogDescription = blogDescription
+	config.Values.BaseUrlPath = baseUrlPath
+	config.Values.RssLinkUrl = rssLinkUrl
+	config.Values.AuthorName = "Test Author"
+	config.Values.AuthorEmail = "author@test.invalid"
+
+	posts := Posts{
+		&Post{
+			urlPath: "/2021/03/test-post-1",
+			metadata: &PostMetadata{
+				publishDate: time.Date(2021, time.March, 17, 0, 0, 0, 0, time.UTC),
+				updateDate:  time.Date(2021, time.April, 2, 0, 0, 0, 0, time.UTC),
+				tags:        []string{"happy", "testing"},
+			},
+			title:     "Test Post 1",
+			content:   "<p>Preview Paragraph</p><p>More content</p>",
+			preview:   "<p>Preview Paragraph</p>",
+			published: true,
+		},
+		&Post{
+			urlPath: "/2021/02/test-post-2",
+			metadata: &PostMetadata{
+				publishDate: time.Date(2021, time.February, 24, 0, 0, 0, 0, time.UTC),
+			},
+			title:     "Test Post 2",
+			content:   "<p>Content</p>",
+			preview:   "<p>Content</p>",
+			published: true,
+		},
+	}
+
+	tmpDir := t.TempDir()
+	atomFileName := filepath.Join(tmpDir, "atom.xml")
+	postAtomFeed(posts, atomFileName)
+
+	atomFile, err := os.Open(atomFileName)
+	if err != nil {
+		t.Fatalf("Failed to read Atom file '%v': %v", atomFileName, err.Error())
+	}
+
+	atomDecoder := xml.NewDecoder(bufio.NewReader(atomFile))
+	atomXML := &AtomXML{}
+
+	err = atomDecoder.Decode(atomXML)
+	if err != nil {
+		t.Fatalf("Failed to parse Atom file '%v': %v", atomFileName, err.Error())
+	}
+
+	assert := assert.New(t)
+	assert.Equal(blogName, atomXML.Title, "Incorrect Atom feed title")
+	assert.Equal(blogDescription, atomXML.Subtitle, "Incorrect Atom feed subtitle")
+	assert.Equal(rssLinkUrl+baseUrlPath+"/", atomXML.Id, "Incorrect Atom feed id")
+	assert.Equal("2021-04-02T00:00:00Z", atomXML.Updated, "Incorrect Atom feed updated date")
+	assert.Equal("Test Author", atomXML.Author.Name, "Incorrect Atom feed author")
+	assert.Equal("author@test.invalid", atomXML.Author.Email, "Incorrect Atom feed author email")
+
+	entries := atomXML.Entries
+	if len(entries) != len(posts) {
+		t.Fatalf("Expected %v entries in Atom XML got %v", len(posts), len(entries))
+	}
+
+	expectedUpdated := []string{"2021-04-02T00:00:00Z", "2021-02-24T00:00:00Z"}
+	for i, entry := range entries {
+		assert.Equal(posts[i].title, entry.Title, "Incorrect title for post %v", i)
+		assert.Equal(rssLinkUrl+posts[i].urlPath, entry.Id, "Incorrect id for post %v", i)
+		assert.Equal(rssLinkUrl+posts[i].urlPath, entry.Links[0].Href, "Incorrect link for post %v", i)
+		assert.Equal(expectedUpdated[i], entry.Updated, "Incorrect updated date for post %v", i)
+		assert.Equal(posts[i].metadata.publishDate.Format(AtomDateFormat), entry.Published, "Incorrect published date for post %v", i)
+		assert.Equal("html", entry.Content.Type, "Incorrect content type for post %v", i)
+		assert.Equal(posts[i].content, entry.Content.Body, "Incorrect content for post %v", i)
+		assert.Equal(len(posts[i].metadata.tags), len(entry.Categories), "Incorrect categories for post %v", i)
+	}
+}
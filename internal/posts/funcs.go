@@ -0,0 +1,389 @@
+package posts
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gomarkdown/markdown"
+
+	"github.com/cswilson90/tribo/internal/config"
+)
+
+// templateDateFormats are the formats dateFormat tries when it's given a date as a string.
+var templateDateFormats = []string{dateFormat, "2 Jan 2006", time.RFC3339}
+
+// hasUrlScheme matches URLs that have a scheme such as "https:" or are protocol relative.
+var hasUrlScheme = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9+.-]*:|//)`)
+
+/*
+	templateFuncs returns the functions available to all templates.
+
+	The functions are:
+
+		dateFormat LAYOUT DATE   formats a time.Time, or a date string, using a Go time layout
+		slugify STRING           converts a string to a name safe for URLs in the same way as tags
+		relURL PATH              prefixes a relative path with the baseUrlPath
+		absURL PATH              converts a path into an absolute URL using rssLinkUrl
+		truncateWords N TEXT     removes HTML from text and cuts it down to at most N words
+		markdownify STRING       renders a markdown string as HTML
+		jsonify VALUE            encodes a value as JSON
+		add A B, sub A B         integer addition and subtraction
+		where LIST FIELD VALUE   returns the items of a list whose field equals, or contains, a value
+		sortBy LIST FIELD [desc] returns a copy of a list sorted by a field
+		first N LIST             returns the first N items of a list
+*/
+func templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"dateFormat":    templateDateFormat,
+		"slugify":       slugify,
+		"relURL":        relURL,
+		"absURL":        absURL,
+		"truncateWords": truncateWords,
+		"markdownify":   markdownify,
+		"jsonify":       jsonify,
+		"add":           func(a, b int) int { return a + b },
+		"sub":           func(a, b int) int { return a - b },
+		"where":         where,
+		"sortBy":        sortBy,
+		"first":         first,
+	}
+}
+
+// templateDateFormat formats a date using a Go time layout.
+// The date can be a time.Time or a string in one of the templateDateFormats.
+func templateDateFormat(layout string, date interface{}) (string, error) {
+	switch d := date.(type) {
+	case time.Time:
+		return d.Format(layout), nil
+	case string:
+		for _, format := range templateDateFormats {
+			parsed, err := time.Parse(format, d)
+			if err == nil {
+				return parsed.Format(layout), nil
+			}
+		}
+		return "", fmt.Errorf("Could not parse date '%v'", d)
+	}
+
+	return "", fmt.Errorf("Can't format date of type %T", date)
+}
+
+// slugify converts a string to a name which is safe to use in URLs.
+// It uses the same rules as the link names of posts and tags.
+func slugify(s string) string {
+	return makeLinkName(s, "")
+}
+
+// relURL prefixes a relative path with the baseUrlPath config option.
+// Absolute URLs and paths starting with "/" are returned unchanged.
+func relURL(urlPath string) string {
+	if hasUrlScheme.MatchString(urlPath) || strings.HasPrefix(urlPath, "/") {
+		return urlPath
+	}
+
+	return config.Values.BaseUrlPath + "/" + urlPath
+}
+
+// absURL converts a path into an absolute URL using the rssLinkUrl config option.
+// Relative paths are prefixed with the baseUrlPath first. Absolute URLs are returned unchanged.
+func absURL(urlPath string) string {
+	if hasUrlScheme.MatchString(urlPath) {
+		return urlPath
+	}
+
+	return absoluteUrl(relURL(urlPath))
+}
+
+// truncateWords removes any HTML tags from some text and cuts it down to at most maxWords words.
+// An ellipsis is added if any words were removed.
+func truncateWords(maxWords int, text interface{}) string {
+	words := strings.Fields(htmlToText(fmt.Sprint(text)))
+	if len(words) <= maxWords {
+		return strings.Join(words, " ")
+	}
+
+	return strings.Join(words[:maxWords], " ") + "…"
+}
+
+// markdownify renders a markdown string as HTML.
+// If the output is a single paragraph the surrounding paragraph tags are removed so the
+// result can be used inline.
+func markdownify(mdText string) template.HTML {
+	mdConfig := config.Values.Markdown
+	output := string(markdown.ToHTML([]byte(mdText), markdownParser(mdConfig), htmlRenderer(mdConfig)))
+
+	return template.HTML(stripParagraph(output))
+}
+
+// stripParagraph removes the surrounding paragraph tags from some HTML if it's a single paragraph.
+// Other HTML is returned with just the surrounding whitespace removed.
+func stripParagraph(htmlText string) string {
+	output := strings.TrimSpace(htmlText)
+	if strings.Count(output, "<p>") == 1 && strings.HasPrefix(output, "<p>") && strings.HasSuffix(output, "</p>") {
+		output = removeOpeningPTag.ReplaceAllLiteralString(output, "")
+		output = removeClosingPTag.ReplaceAllLiteralString(output, "")
+	}
+
+	return output
+}
+
+// jsonify encodes a value as JSON.
+func jsonify(value interface{}) (template.JS, error) {
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	return template.JS(valueJSON), nil
+}
+
+// where returns the items of a list which have a field equal to a value.
+// If the field is a list the items which contain the value are returned instead
+// e.g. where .Posts "Tags" "go" returns the posts tagged "go".
+// Items can be structs, pointers to structs or maps with string keys.
+func where(list interface{}, field string, value interface{}) (interface{}, error) {
+	listValue, err := listReflectValue(list)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := reflect.MakeSlice(listValue.Type(), 0, listValue.Len())
+	for i := 0; i < listValue.Len(); i++ {
+		item := listValue.Index(i)
+		fieldValue, err := itemField(item, field)
+		if err != nil {
+			return nil, err
+		}
+
+		if fieldMatches(fieldValue, value) {
+			matches = reflect.Append(matches, item)
+		}
+	}
+
+	return matches.Interface(), nil
+}
+
+// sortBy returns a copy of a list sorted by a field of its items.
+// Fields can be strings, numbers or times. The list is sorted in ascending order unless
+// the order "desc" is given.
+func sortBy(list interface{}, field string, order ...string) (interface{}, error) {
+	listValue, err := listReflectValue(list)
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := reflect.MakeSlice(listValue.Type(), listValue.Len(), listValue.Len())
+	reflect.Copy(sorted, listValue)
+
+	keys := make([]reflect.Value, sorted.Len())
+	for i := range keys {
+		key, err := itemField(sorted.Index(i), field)
+		if err != nil {
+			return nil, err
+		}
+
+		// Copy the key as fields of structs in the list change when the list is sorted
+		if key.IsValid() {
+			key = reflect.ValueOf(key.Interface())
+		}
+		keys[i] = key
+	}
+
+	descending := len(order) > 0 && strings.ToLower(order[0]) == "desc"
+
+	var sortErr error
+	swap := reflect.Swapper(sorted.Interface())
+	sort.Stable(&fieldSorter{
+		len: sorted.Len(),
+		less: func(i, j int) bool {
+			a, b := keys[i], keys[j]
+			if descending {
+				a, b = b, a
+			}
+
+			less, err := lessValue(a, b)
+			if err != nil {
+				sortErr = err
+			}
+			return less
+		},
+		swap: func(i, j int) {
+			swap(i, j)
+			keys[i], keys[j] = keys[j], keys[i]
+		},
+	})
+	if sortErr != nil {
+		return nil, sortErr
+	}
+
+	return sorted.Interface(), nil
+}
+
+// first returns the first n items of a list.
+// The whole list is returned if it has fewer than n items.
+func first(n int, list interface{}) (interface{}, error) {
+	listValue, err := listReflectValue(list)
+	if err != nil {
+		return nil, err
+	}
+
+	if n < 0 {
+		n = 0
+	}
+	if n > listValue.Len() {
+		n = listValue.Len()
+	}
+
+	return listValue.Slice(0, n).Interface(), nil
+}
+
+// fieldSorter implements sort.Interface using functions.
+type fieldSorter struct {
+	len  int
+	less func(i, j int) bool
+	swap func(i, j int)
+}
+
+func (s *fieldSorter) Len() int           { return s.len }
+func (s *fieldSorter) Less(i, j int) bool { return s.less(i, j) }
+func (s *fieldSorter) Swap(i, j int)      { s.swap(i, j) }
+
+// listReflectValue returns the reflect.Value of a slice or array given to a template function.
+func listReflectValue(list interface{}) (reflect.Value, error) {
+	listValue := indirect(reflect.ValueOf(list))
+	if listValue.Kind() != reflect.Slice && listValue.Kind() != reflect.Array {
+		return reflect.Value{}, fmt.Errorf("Expected a list but got %T", list)
+	}
+
+	if listValue.Kind() == reflect.Array {
+		// Arrays can't be sliced or appended to so convert to a slice
+		slice := reflect.MakeSlice(reflect.SliceOf(listValue.Type().Elem()), listValue.Len(), listValue.Len())
+		reflect.Copy(slice, listValue)
+		listValue = slice
+	}
+
+	return listValue, nil
+}
+
+// itemField returns the value of a field of a struct or a key of a map with string keys.
+func itemField(item reflect.Value, field string) (reflect.Value, error) {
+	item = indirect(item)
+
+	switch item.Kind() {
+	case reflect.Struct:
+		fieldValue := item.FieldByName(field)
+		if !fieldValue.IsValid() {
+			return reflect.Value{}, fmt.Errorf("Field '%v' doesn't exist in %v", field, item.Type())
+		}
+		return indirect(fieldValue), nil
+	case reflect.Map:
+		if item.Type().Key().Kind() != reflect.String {
+			return reflect.Value{}, fmt.Errorf("Can't get field '%v' of %v", field, item.Type())
+		}
+		return indirect(item.MapIndex(reflect.ValueOf(field).Convert(item.Type().Key()))), nil
+	}
+
+	return reflect.Value{}, fmt.Errorf("Can't get field '%v' of %v", field, item.Type())
+}
+
+// indirect follows pointers and interfaces until it reaches a concrete value.
+func indirect(value reflect.Value) reflect.Value {
+	for value.IsValid() && (value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface) {
+		if value.IsNil() {
+			return reflect.Value{}
+		}
+		value = value.Elem()
+	}
+
+	return value
+}
+
+// fieldMatches returns true if a field equals a value or, if the field is a list,
+// it contains the value.
+func fieldMatches(fieldValue reflect.Value, value interface{}) bool {
+	if !fieldValue.IsValid() {
+		return value == nil
+	}
+
+	if fieldValue.Kind() == reflect.Slice || fieldValue.Kind() == reflect.Array {
+		if _, isList := value.([]interface{}); !isList {
+			for i := 0; i < fieldValue.Len(); i++ {
+				if valuesEqual(indirect(fieldValue.Index(i)), value) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	return valuesEqual(fieldValue, value)
+}
+
+// valuesEqual compares a reflect.Value to a value given in a template.
+// Numbers are compared by value so an int field matches a number given in a template.
+func valuesEqual(fieldValue reflect.Value, value interface{}) bool {
+	if !fieldValue.IsValid() {
+		return value == nil
+	}
+
+	other := reflect.ValueOf(value)
+	if isNumber(fieldValue) && isNumber(other) {
+		return toFloat(fieldValue) == toFloat(other)
+	}
+
+	return reflect.DeepEqual(fieldValue.Interface(), value)
+}
+
+// lessValue returns true if a is less than b.
+// Strings, numbers and times can be compared.
+func lessValue(a, b reflect.Value) (bool, error) {
+	if !a.IsValid() || !b.IsValid() {
+		// Missing values are sorted first
+		return !a.IsValid() && b.IsValid(), nil
+	}
+
+	if isNumber(a) && isNumber(b) {
+		return toFloat(a) < toFloat(b), nil
+	}
+	if a.Kind() == reflect.String && b.Kind() == reflect.String {
+		return a.String() < b.String(), nil
+	}
+
+	aTime, aIsTime := a.Interface().(time.Time)
+	bTime, bIsTime := b.Interface().(time.Time)
+	if aIsTime && bIsTime {
+		return aTime.Before(bTime), nil
+	}
+
+	return false, fmt.Errorf("Can't compare values of type %v and %v", a.Type(), b.Type())
+}
+
+// isNumber returns true if a value is an integer or floating point number.
+func isNumber(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+
+	return false
+}
+
+// toFloat converts a number to a float64.
+func toFloat(value reflect.Value) float64 {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint())
+	}
+
+	return value.Float()
+}
@@ -0,0 +1,92 @@
+package posts
+
+import (
+	"bytes"
+	"html/template"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cswilson90/tribo/internal/config"
+)
+
+type funcTestItem struct {
+	Name  string
+	Count int
+	Date  time.Time
+	Tags  []string
+}
+
+var funcTestItems = []funcTestItem{
+	{Name: "b", Count: 2, Date: time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC), Tags: []string{"go"}},
+	{Name: "c", Count: 1, Date: time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC), Tags: []string{"go", "web"}},
+	{Name: "a", Count: 3, Date: time.Date(2021, time.February, 1, 0, 0, 0, 0, time.UTC)},
+}
+
+var templateFuncTests = []struct {
+	template string
+	expected string
+}{
+	{`{{dateFormat "Jan 2006" .Date}}`, "Mar 2021"},
+	{`{{dateFormat "2006" "17 Mar 2021"}}`, "2021"},
+	{`{{.Date | dateFormat "2006-01-02"}}`, "2021-03-17"},
+	{`{{slugify "Hello World"}}`, "hello-world"},
+	{`{{relURL "blog.css"}}`, "/blog/blog.css"},
+	{`{{relURL "/blog/2021/03/post"}}`, "/blog/2021/03/post"},
+	{`{{absURL "blog.css"}}`, "https://test.invalid/blog/blog.css"},
+	{`{{absURL "/blog/2021/03/post"}}`, "https://test.invalid/blog/2021/03/post"},
+	{`{{absURL "https://example.com/a"}}`, "https://example.com/a"},
+	{`{{truncateWords 3 "<p>one two <b>three</b> four</p>"}}`, "one two three…"},
+	{`{{truncateWords 5 "one two"}}`, "one two"},
+	{`{{markdownify "some *emphasis*"}}`, "some <em>emphasis</em>"},
+	{`{{markdownify "text\n\n- item"}}`, "<p>text</p>\n\n<ul>\n<li>item</li>\n</ul>"},
+	{`<script>var x = {{jsonify .Tags}};</script>`, `<script>var x = ["a","b"];</script>`},
+	{`{{add 1 2}} {{sub 5 2}}`, "3 3"},
+	{`{{range where .Items "Tags" "web"}}{{.Name}}{{end}}`, "c"},
+	{`{{range where .Items "Count" 2}}{{.Name}}{{end}}`, "b"},
+	{`{{range sortBy .Items "Name"}}{{.Name}}{{end}}`, "abc"},
+	{`{{range sortBy .Items "Count" "desc"}}{{.Name}}{{end}}`, "abc"},
+	{`{{range sortBy .Items "Date"}}{{.Name}}{{end}}`, "cab"},
+	{`{{range first 2 .Items}}{{.Name}}{{end}}`, "bc"},
+	{`{{range first 5 .Items}}{{.Name}}{{end}}`, "bca"},
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	config.Init([]string{})
+	config.Values.BaseUrlPath = baseUrlPath
+	config.Values.RssLinkUrl = rssLinkUrl
+
+	data := map[string]interface{}{
+		"Date":  time.Date(2021, time.March, 17, 0, 0, 0, 0, time.UTC),
+		"Tags":  []string{"a", "b"},
+		"Items": funcTestItems,
+	}
+
+	assert := assert.New(t)
+	for _, tc := range templateFuncTests {
+		testTmpl, err := template.New("test").Funcs(templateFuncs()).Parse(tc.template)
+		if err != nil {
+			t.Errorf("Failed to parse template '%v': %v", tc.template, err.Error())
+			continue
+		}
+
+		var output bytes.Buffer
+		err = testTmpl.Execute(&output, data)
+		if assert.NoError(err, "Failed to execute template '%v'", tc.template) {
+			assert.Equal(tc.expected, output.String(), "Incorrect output for template '%v'", tc.template)
+		}
+	}
+
+	// Invalid arguments should fail when the template is executed
+	errorTemplates := []string{
+		`{{dateFormat "2006" "not a date"}}`,
+		`{{where .Date "Name" "a"}}`,
+		`{{sortBy .Items "Missing"}}`,
+	}
+	for _, errorTemplate := range errorTemplates {
+		testTmpl := template.Must(template.New("test").Funcs(templateFuncs()).Parse(errorTemplate))
+		var output bytes.Buffer
+		assert.Error(testTmpl.Execute(&output, data), "Expected error executing template '%v'", errorTemplate)
+	}
+}
@@ -0,0 +1,120 @@
+package posts
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/alecthomas/chroma"
+	chromahtml "github.com/alecthomas/chroma/formatters/html"
+	"github.com/alecthomas/chroma/lexers"
+	"github.com/alecthomas/chroma/styles"
+	"github.com/gomarkdown/markdown/ast"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/cswilson90/tribo/internal/config"
+)
+
+// highlightCSSFile is the name of the stylesheet saved in the root of the output when
+// highlighting uses CSS classes.
+const highlightCSSFile = "highlight.css"
+
+var (
+	// highlightStyle is the style used to colour highlighted code.
+	// It's nil if code shouldn't be highlighted.
+	highlightStyle *chroma.Style
+	// highlightFormatter converts highlighted code into HTML.
+	highlightFormatter *chromahtml.Formatter
+)
+
+// initHighlighting sets up syntax highlighting of code blocks from the config.
+// An error is returned if the highlightStyle config option isn't a known style.
+func initHighlighting() error {
+	highlightStyle = nil
+	highlightFormatter = nil
+	if config.Values.NoHighlight {
+		return nil
+	}
+
+	style, exists := styles.Registry[config.Values.HighlightStyle]
+	if !exists {
+		return fmt.Errorf("Unknown highlight style '%v'", config.Values.HighlightStyle)
+	}
+
+	highlightStyle = style
+	highlightFormatter = chromahtml.New(chromahtml.WithClasses(config.Values.HighlightClasses))
+
+	return nil
+}
+
+// highlightCode writes the HTML for a code block with syntax highlighting.
+// Returns false without writing anything if the code block can't be highlighted because
+// highlighting is disabled or the code block doesn't have a known language.
+func highlightCode(w io.Writer, codeBlock *ast.CodeBlock) bool {
+	if highlightStyle == nil {
+		return false
+	}
+
+	// The info string can have extra words after the language e.g. "go {linenos=true}"
+	info := strings.Fields(string(codeBlock.Info))
+	if len(info) == 0 {
+		return false
+	}
+
+	lexer := lexers.Get(info[0])
+	if lexer == nil {
+		return false
+	}
+
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, string(codeBlock.Literal))
+	if err != nil {
+		log.Warnf("Failed to highlight '%v' code block: "+err.Error(), info[0])
+		return false
+	}
+
+	err = highlightFormatter.Format(w, highlightStyle, iterator)
+	if err != nil {
+		log.Warnf("Failed to highlight '%v' code block: "+err.Error(), info[0])
+		return false
+	}
+
+	return true
+}
+
+// highlightCSS saves the stylesheet for highlighted code in the output directory.
+// The stylesheet is only needed if highlighting uses CSS classes instead of inline styles.
+// If it isn't needed a stylesheet from an earlier build is removed unless output cleanup
+// is turned off or the stylesheet is one of the static files.
+func highlightCSS(outputFile string) error {
+	if highlightFormatter == nil || !config.Values.HighlightClasses {
+		if config.Values.NoOutputCleanup || staticPathExists(highlightCSSFile) {
+			return nil
+		}
+
+		err := os.Remove(outputFile)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	file, err := os.Create(outputFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	log.Infof("Writing highlight stylesheet to '%v'", outputFile)
+	return highlightFormatter.WriteCSS(file, highlightStyle)
+}
+
+// highlightCSSUrl returns the URL of the stylesheet for highlighted code.
+// It's empty if there isn't a stylesheet.
+func highlightCSSUrl() string {
+	if config.Values.NoHighlight || !config.Values.HighlightClasses {
+		return ""
+	}
+
+	return config.Values.BaseUrlPath + "/" + highlightCSSFile
+}
@@ -0,0 +1,74 @@
+package posts
+
+import (
+	"path/filepath"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cswilson90/tribo/internal/config"
+)
+
+const codeMarkdown = "# Code\n\n```go\nfunc main() {}\n```\n\n```nosuchlanguage\nplain text\n```\n"
+
+func TestHighlightCode(t *testing.T) {
+	config.Init([]string{})
+	assert := assert.New(t)
+	mdConfig := config.Values.Markdown
+
+	// Default is inline styles
+	err := initHighlighting()
+	if err != nil {
+		t.Fatalf("Failed to set up highlighting: %v", err.Error())
+	}
+	content := renderMarkdown(parseMarkdown([]byte(codeMarkdown), mdConfig), renderPost, mdConfig)
+	assert.Contains(content, `<span style="`, "Code not highlighted with inline styles")
+	assert.Contains(content, `<code class="language-nosuchlanguage">plain text`, "Unknown language not rendered as plain code")
+
+	config.Values.HighlightClasses = true
+	err = initHighlighting()
+	if err != nil {
+		t.Fatalf("Failed to set up highlighting with CSS classes: %v", err.Error())
+	}
+	content = renderMarkdown(parseMarkdown([]byte(codeMarkdown), mdConfig), renderPost, mdConfig)
+	assert.Contains(content, `<pre tabindex="0" class="chroma">`, "Code not highlighted with CSS classes")
+	assert.NotContains(content, `<span style="`, "Inline styles used with CSS classes")
+
+	config.Values.NoHighlight = true
+	err = initHighlighting()
+	if err != nil {
+		t.Fatalf("Failed to turn off highlighting: %v", err.Error())
+	}
+	content = renderMarkdown(parseMarkdown([]byte(codeMarkdown), mdConfig), renderPost, mdConfig)
+	assert.Contains(content, `<code class="language-go">func main() {}`, "Code highlighted when highlighting is disabled")
+
+	config.Values.NoHighlight = false
+	config.Values.HighlightStyle = "no-such-style"
+	assert.Error(initHighlighting(), "Expected error for unknown highlight style")
+	highlightStyle = nil
+}
+
+func TestHighlightCSS(t *testing.T) {
+	config.Init([]string{})
+	log.SetLevel(log.FatalLevel)
+	config.Values.StaticDir = staticDir
+	config.Values.TemplateDir = templateDir
+
+	assert := assert.New(t)
+
+	tmpDir := t.TempDir()
+	BuildPosts(inputDir, tmpDir)
+	assert.False(fileExists(filepath.Join(tmpDir, highlightCSSFile)), "Stylesheet written for inline styles")
+
+	config.Values.HighlightClasses = true
+	tmpDir = t.TempDir()
+	BuildPosts(inputDir, tmpDir)
+	assert.FileExists(filepath.Join(tmpDir, highlightCSSFile), "Stylesheet not written for CSS classes")
+	assert.Equal("/"+highlightCSSFile, comData().HighlightCssUrl, "Incorrect stylesheet URL")
+
+	// The stylesheet is removed when it's no longer needed
+	config.Values.HighlightClasses = false
+	BuildPosts(inputDir, tmpDir)
+	assert.NoFileExists(filepath.Join(tmpDir, highlightCSSFile), "Stylesheet not removed for inline styles")
+}
@@ -0,0 +1,113 @@
+package posts
+
+import (
+	"encoding/json"
+	"html"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/cswilson90/tribo/internal/config"
+)
+
+// JSONFeedVersion is the URL of the version of the JSON Feed specification the feed follows.
+const JSONFeedVersion = "https://jsonfeed.org/version/1.1"
+
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// JSONFeed describes the top level format of the JSON Feed.
+// The feed corresponds to the whole blog.
+// See the JSON Feed specification for more information.
+type JSONFeed struct {
+	Version     string            `json:"version"`
+	Title       string            `json:"title"`
+	HomePageUrl string            `json:"home_page_url"`
+	FeedUrl     string            `json:"feed_url"`
+	Description string            `json:"description,omitempty"`
+	Authors     []*JSONFeedAuthor `json:"authors,omitempty"`
+	Items       []*JSONFeedItem   `json:"items"`
+}
+
+// JSONFeedItem describes the format of a single item in the JSON Feed.
+// An item in the JSON Feed corresponds to a single blog post.
+// See the JSON Feed specification for more information.
+type JSONFeedItem struct {
+	Id            string   `json:"id"`
+	Url           string   `json:"url"`
+	Title         string   `json:"title"`
+	ContentHtml   string   `json:"content_html"`
+	Summary       string   `json:"summary,omitempty"`
+	DatePublished string   `json:"date_published"`
+	DateModified  string   `json:"date_modified,omitempty"`
+	Tags          []string `json:"tags,omitempty"`
+}
+
+// JSONFeedAuthor describes the format of the author of the JSON Feed.
+type JSONFeedAuthor struct {
+	Name string `json:"name"`
+	Url  string `json:"url,omitempty"`
+}
+
+// postJSONFeed outputs the JSON Feed for the blog.
+// The JSON Feed is saved in "feed.json" in the root directory of the blog.
+// The posts should be sorted by date published.
+func postJSONFeed(posts Posts, outputFile string) {
+	if config.Values.NoJsonFeed {
+		log.Infof("Not generating JSON Feed file as it's disabled in the config")
+		return
+	}
+
+	log.Infof("Writing JSON Feed to '%v'", outputFile)
+
+	// Add newest posts to JSON Feed
+	maxPosts := feedMaxPosts(posts)
+
+	items := make([]*JSONFeedItem, maxPosts)
+	for i := 0; i < maxPosts; i++ {
+		post := posts[i]
+		postLink := absoluteUrl(post.urlPath)
+
+		items[i] = &JSONFeedItem{
+			Id:            postLink,
+			Url:           postLink,
+			Title:         post.title,
+			ContentHtml:   post.content,
+			Summary:       htmlToText(post.preview),
+			DatePublished: post.metadata.publishDate.Format(AtomDateFormat),
+			Tags:          post.metadata.tags,
+		}
+		if !post.metadata.updateDate.IsZero() {
+			items[i].DateModified = post.metadata.lastUpdated().Format(AtomDateFormat)
+		}
+	}
+
+	author := feedAuthor()
+	feed := &JSONFeed{
+		Version:     JSONFeedVersion,
+		Title:       config.Values.BlogName,
+		HomePageUrl: absoluteUrl(config.Values.BaseUrlPath + "/"),
+		FeedUrl:     absoluteUrl(config.Values.BaseUrlPath + "/feed.json"),
+		Description: config.Values.BlogDescription,
+		Authors:     []*JSONFeedAuthor{{Name: author.Name, Url: author.Uri}},
+		Items:       items,
+	}
+
+	feedJSON, err := json.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		log.Errorf("Failed to encode JSON Feed: " + err.Error())
+		return
+	}
+
+	err = ioutil.WriteFile(outputFile, feedJSON, 0664)
+	if err != nil {
+		log.Errorf("Failed to write JSON Feed file '%v': "+err.Error(), outputFile)
+	}
+}
+
+// htmlToText converts a HTML fragment to plain text by removing all the tags.
+func htmlToText(htmlText string) string {
+	text := htmlTag.ReplaceAllString(htmlText, "")
+	return strings.TrimSpace(html.UnescapeString(text))
+}
@@ -0,0 +1,84 @@
+package posts
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cswilson90/tribo/internal/config"
+)
+
+func TestJSONFeed(t *testing.T) {
+	config.Init([]string{})
+	config.Values.BlogName = blogName
+	config.Values.BlogDescription = blogDescription
+	config.Values.BaseUrlPath = baseUrlPath
+	config.Values.RssLinkUrl = rssLinkUrl
+
+	posts := Posts{
+		&Post{
+			urlPath: "/2021/03/test-post-1",
+			metadata: &PostMetadata{
+				publishDate: time.Date(2021, time.March, 17, 0, 0, 0, 0, time.UTC),
+				updateDate:  time.Date(2021, time.April, 2, 0, 0, 0, 0, time.UTC),
+				tags:        []string{"happy", "testing"},
+			},
+			title:     "Test Post 1",
+			content:   "<p>Preview <em>Paragraph</em> &amp; more</p><p>More content</p>",
+			preview:   "<p>Preview <em>Paragraph</em> &amp; more</p>",
+			published: true,
+		},
+		&Post{
+			urlPath: "/2021/02/test-post-2",
+			metadata: &PostMetadata{
+				publishDate: time.Date(2021, time.February, 24, 0, 0, 0, 0, time.UTC),
+			},
+			title:     "Test Post 2",
+			content:   "<p>Content</p>",
+			preview:   "<p>Content</p>",
+			published: true,
+		},
+	}
+
+	tmpDir := t.TempDir()
+	feedFileName := filepath.Join(tmpDir, "feed.json")
+	postJSONFeed(posts, feedFileName)
+
+	feedData, err := ioutil.ReadFile(feedFileName)
+	if err != nil {
+		t.Fatalf("Failed to read JSON Feed file '%v': %v", feedFileName, err.Error())
+	}
+
+	feed := &JSONFeed{}
+	err = json.Unmarshal(feedData, feed)
+	if err != nil {
+		t.Fatalf("Failed to parse JSON Feed file '%v': %v", feedFileName, err.Error())
+	}
+
+	assert := assert.New(t)
+	assert.Equal(JSONFeedVersion, feed.Version, "Incorrect JSON Feed version")
+	assert.Equal(blogName, feed.Title, "Incorrect JSON Feed title")
+	assert.Equal(rssLinkUrl+baseUrlPath+"/", feed.HomePageUrl, "Incorrect JSON Feed home page URL")
+	assert.Equal(rssLinkUrl+baseUrlPath+"/feed.json", feed.FeedUrl, "Incorrect JSON Feed feed URL")
+
+	if len(feed.Items) != len(posts) {
+		t.Fatalf("Expected %v items in JSON Feed got %v", len(posts), len(feed.Items))
+	}
+
+	expectedSummaries := []string{"Preview Paragraph & more", "Content"}
+	expectedModified := []string{"2021-04-02T00:00:00Z", ""}
+	for i, item := range feed.Items {
+		assert.Equal(rssLinkUrl+posts[i].urlPath, item.Id, "Incorrect id for post %v", i)
+		assert.Equal(rssLinkUrl+posts[i].urlPath, item.Url, "Incorrect url for post %v", i)
+		assert.Equal(posts[i].title, item.Title, "Incorrect title for post %v", i)
+		assert.Equal(posts[i].content, item.ContentHtml, "Incorrect content for post %v", i)
+		assert.Equal(expectedSummaries[i], item.Summary, "Incorrect summary for post %v", i)
+		assert.Equal(posts[i].metadata.publishDate.Format(AtomDateFormat), item.DatePublished, "Incorrect published date for post %v", i)
+		assert.Equal(expectedModified[i], item.DateModified, "Incorrect modified date for post %v", i)
+		assert.Equal(posts[i].metadata.tags, item.Tags, "Incorrect tags for post %v", i)
+	}
+}
@@ -0,0 +1,297 @@
+package posts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/cswilson90/tribo/internal/config"
+)
+
+const (
+	// manifestFile is the name of the build manifest saved in the root of the output directory.
+	manifestFile = ".tribo-manifest.json"
+	// manifestVersion should be incremented whenever the format of the manifest, or the way
+	// the content cached in it is rendered, changes so manifests from older versions of Tribo
+	// are ignored.
+	manifestVersion = 5
+)
+
+var (
+	// lastManifest is the manifest loaded from the output of the previous build.
+	lastManifest *buildManifest
+	// currentManifest records the posts built during the current build.
+	currentManifest *buildManifest
+)
+
+/*
+	buildManifest records the inputs of a build so that posts whose inputs haven't
+	changed can be skipped on the next build.
+
+	The manifest is saved as JSON in the root of the output directory.
+*/
+type buildManifest struct {
+	Version int `json:"version"`
+	// GlobalHash is a hash of the config and templates.
+	// If it changes between builds every post is rebuilt.
+	GlobalHash string `json:"globalHash"`
+	// Posts maps the input directory of a post to the details of the last time it was built.
+	Posts map[string]*manifestPost `json:"posts"`
+	// Pages lists the paths of the standalone pages relative to the root of the output so
+	// the output of pages which have been removed can be cleaned up.
+	Pages []string `json:"pages"`
+
+	lock sync.Mutex
+}
+
+// manifestPost stores the details of a single post in the build manifest.
+// As well as the hash of the inputs it stores the rendered content of the post so
+// the post list and RSS feed can be generated without parsing the post again.
+type manifestPost struct {
+	InputHash string `json:"inputHash"`
+	OutputDir string `json:"outputDir"`
+	// NeighboursHash is a hash of the inputs of the previous and next posts linked to
+	// from the post's page.
+	NeighboursHash string `json:"neighboursHash"`
+
+	Title   string     `json:"title"`
+	Content string     `json:"content"`
+	Preview string     `json:"preview"`
+	TOC     []tocEntry `json:"toc"`
+}
+
+// newBuildManifest creates an empty manifest.
+func newBuildManifest(globalHash string) *buildManifest {
+	return &buildManifest{
+		Version:    manifestVersion,
+		GlobalHash: globalHash,
+		Posts:      make(map[string]*manifestPost),
+		Pages:      make([]string, 0),
+	}
+}
+
+// loadManifest loads the manifest saved by the previous build from the output directory.
+// If there is no usable manifest, or the global hash doesn't match, an empty manifest is
+// returned so that every post is rebuilt. The pages from the previous build are kept in the
+// empty manifest if the manifest is from the same version of Tribo.
+func loadManifest(outputDir, globalHash string) *buildManifest {
+	emptyManifest := newBuildManifest(globalHash)
+
+	manifestPath := filepath.Join(outputDir, manifestFile)
+	data, err := ioutil.ReadFile(manifestPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warnf("Failed to read build manifest '%v': "+err.Error(), manifestPath)
+		}
+		return emptyManifest
+	}
+
+	manifest := &buildManifest{}
+	err = json.Unmarshal(data, manifest)
+	if err != nil {
+		log.Warnf("Failed to parse build manifest '%v': "+err.Error(), manifestPath)
+		return emptyManifest
+	}
+
+	if manifest.Version != manifestVersion || manifest.Posts == nil {
+		log.Infof("Build manifest is from a different version of Tribo, rebuilding all posts")
+		return emptyManifest
+	}
+
+	// The pages are needed to clean up the output even if every post is rebuilt
+	if manifest.Pages != nil {
+		emptyManifest.Pages = manifest.Pages
+	}
+	if config.Values.FullRebuild {
+		log.Infof("Full rebuild requested, ignoring build manifest")
+		return emptyManifest
+	}
+	if manifest.GlobalHash != globalHash {
+		log.Infof("Config or templates have changed, rebuilding all posts")
+		return emptyManifest
+	}
+
+	return manifest
+}
+
+// save writes the manifest to the root of the output directory.
+func (m *buildManifest) save(outputDir string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(outputDir, manifestFile), data, 0664)
+}
+
+// lookup returns the manifest entry for a post if the inputs of the post haven't changed
+// since it was recorded. Returns nil if the post needs to be rebuilt.
+func (m *buildManifest) lookup(dir, inputHash string) *manifestPost {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	entry, exists := m.Posts[dir]
+	if !exists || entry.InputHash != inputHash {
+		return nil
+	}
+
+	return entry
+}
+
+// record adds a built post to the manifest.
+func (m *buildManifest) record(p *Post) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.Posts[p.dir] = &manifestPost{
+		InputHash:      p.inputHash,
+		OutputDir:      p.outputDir,
+		NeighboursHash: p.neighboursHash,
+		Title:          p.title,
+		Content:        p.content,
+		Preview:        p.preview,
+		TOC:            p.toc,
+	}
+}
+
+// recordPage adds the path of a built page to the manifest.
+func (m *buildManifest) recordPage(relPath string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.Pages = append(m.Pages, relPath)
+}
+
+// globalInputsHash returns a hash of all the inputs that affect every post i.e. the
+// config and the templates.
+func globalInputsHash() (string, error) {
+	hash := sha256.New()
+
+	// Options which don't affect the output shouldn't cause everything to be rebuilt
+	hashedConfig := config.Values
+	hashedConfig.Parallelism = 0
+	hashedConfig.FullRebuild = false
+	configJSON, err := json.Marshal(hashedConfig)
+	if err != nil {
+		return "", err
+	}
+	hash.Write(configJSON)
+
+	// The current year is passed to the templates so pages need rebuilding when it changes
+	io.WriteString(hash, time.Now().Format("2006"))
+
+	// Every post links to the standalone pages so posts need rebuilding when they change
+	pagesJSON, err := json.Marshal(pageLinks())
+	if err != nil {
+		return "", err
+	}
+	hash.Write(pagesJSON)
+
+	// The default templates are part of the executable so can change between versions
+	err = hashFS(hash, defaultTemplates, defaultTemplatesDir)
+	if err != nil {
+		return "", err
+	}
+
+	// The template directories don't have to exist if the default templates are used
+	for _, dir := range templateDirs() {
+		if !fileExists(dir) {
+			continue
+		}
+
+		io.WriteString(hash, dir)
+		err = hashPath(hash, dir)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// neighboursHash returns a hash of the inputs of the posts either side of a post.
+// Either post can be nil if there isn't one.
+func neighboursHash(prev, next *Post) string {
+	hash := sha256.New()
+	for _, post := range []*Post{prev, next} {
+		if post != nil {
+			fmt.Fprintf(hash, "%v\x00%v\x00", post.dir, post.inputHash)
+		}
+		io.WriteString(hash, "\x00")
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// pathHash returns a hash of the contents of a file or directory.
+func pathHash(path string) (string, error) {
+	hash := sha256.New()
+	err := hashPath(hash, path)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// hashPath writes the names and contents of all files under path to a hash.
+// Files are visited in lexical order so the hash is stable between runs.
+func hashPath(hash io.Writer, path string) error {
+	return filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(path, file)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(hash, "%v\x00%v\x00", relPath, info.Size())
+
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		_, err = io.Copy(hash, f)
+		return err
+	})
+}
+
+// hashFS writes the contents of a directory in a file system to a hash.
+func hashFS(hash io.Writer, fsys fs.FS, dir string) error {
+	return fs.WalkDir(fsys, dir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(hash, "%v\x00%v\x00", file, len(data))
+		hash.Write(data)
+
+		return nil
+	})
+}
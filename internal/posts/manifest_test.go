@@ -0,0 +1,92 @@
+package posts
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/otiai10/copy"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cswilson90/tribo/internal/config"
+)
+
+func TestManifest(t *testing.T) {
+	config.Init([]string{})
+	log.SetLevel(log.FatalLevel)
+	config.Values.StaticDir = staticDir
+	config.Values.TemplateDir = templateDir
+
+	assert := assert.New(t)
+	tmpDir := t.TempDir()
+
+	BuildPosts(inputDir, tmpDir)
+
+	if _, err := ioutil.ReadFile(filepath.Join(tmpDir, manifestFile)); err != nil {
+		t.Fatalf("Failed to read build manifest: %v", err.Error())
+	}
+
+	// Overwrite the output of a post, an unchanged post shouldn't be rebuilt
+	postIndex := filepath.Join(tmpDir, "2021/01/2021-01-post-1/index.html")
+	err := ioutil.WriteFile(postIndex, []byte("unchanged"), 0664)
+	if err != nil {
+		t.Fatalf("Failed to write post index file: %v", err.Error())
+	}
+
+	BuildPosts(inputDir, tmpDir)
+
+	content, _ := ioutil.ReadFile(postIndex)
+	assert.Equal("unchanged", string(content), "Unchanged post was rebuilt")
+	assert.Equal(5, len(currentManifest.Posts), "Incorrect number of posts in manifest")
+
+	// A config change should cause all posts to be rebuilt
+	config.Values.BlogName = "Changed Blog"
+	BuildPosts(inputDir, tmpDir)
+
+	content, _ = ioutil.ReadFile(postIndex)
+	assert.NotEqual("unchanged", string(content), "Post not rebuilt after config change")
+}
+
+func TestManifestNeighbours(t *testing.T) {
+	config.Init([]string{})
+	log.SetLevel(log.FatalLevel)
+	config.Values.StaticDir = staticDir
+	config.Values.TemplateDir = templateDir
+
+	postsDir := filepath.Join(t.TempDir(), "posts")
+	err := copy.Copy(inputDir, postsDir)
+	if err != nil {
+		t.Fatalf("Failed to copy test posts: %v", err.Error())
+	}
+
+	assert := assert.New(t)
+	tmpDir := t.TempDir()
+
+	BuildPosts(postsDir, tmpDir)
+
+	// Overwrite the output of a post next to where the new post will be and one that isn't
+	postIndex := filepath.Join(tmpDir, "2021/01/2021-01-post-1/index.html")
+	otherIndex := filepath.Join(tmpDir, "2020/12/post-2-202012/index.html")
+	for _, file := range []string{postIndex, otherIndex} {
+		err = ioutil.WriteFile(file, []byte("unchanged"), 0664)
+		if err != nil {
+			t.Fatalf("Failed to write post index file: %v", err.Error())
+		}
+	}
+
+	_, err = NewPost(postsDir, "New Neighbour", nil, time.Date(2021, time.February, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Failed to create new post: %v", err.Error())
+	}
+
+	BuildPosts(postsDir, tmpDir)
+
+	content, _ := ioutil.ReadFile(postIndex)
+	assert.Contains(string(content), "/2021/02/new-neighbour", "Post not rebuilt after its next post changed")
+
+	content, _ = ioutil.ReadFile(otherIndex)
+	assert.Equal("unchanged", string(content), "Post rebuilt when its neighbours didn't change")
+}
@@ -0,0 +1,80 @@
+package posts
+
+import (
+	"github.com/gomarkdown/markdown/html"
+	"github.com/gomarkdown/markdown/parser"
+
+	"github.com/cswilson90/tribo/internal/config"
+)
+
+// alwaysExtensions are the parser extensions which are used whatever the markdown config is.
+const alwaysExtensions = parser.NoIntraEmphasis | parser.FencedCode | parser.SpaceHeadings |
+	parser.BackslashLineBreak
+
+// markdownParser returns a new markdown parser with the extensions from a markdown config.
+// A parser can only be used once.
+func markdownParser(mdConfig config.MarkdownConfig) *parser.Parser {
+	extensions := alwaysExtensions
+	if mdConfig.Tables {
+		extensions |= parser.Tables
+	}
+	if mdConfig.Strikethrough {
+		extensions |= parser.Strikethrough
+	}
+	if mdConfig.Autolink {
+		extensions |= parser.Autolink
+	}
+	if mdConfig.DefinitionLists {
+		extensions |= parser.DefinitionLists
+	}
+	if mdConfig.MathBlocks {
+		extensions |= parser.MathJax
+	}
+	if mdConfig.HeadingIds {
+		extensions |= parser.HeadingIDs
+	}
+	if mdConfig.Footnotes {
+		extensions |= parser.Footnotes
+	}
+	if mdConfig.Superscript {
+		extensions |= parser.SuperSubscript
+	}
+	if mdConfig.HardLineBreaks {
+		extensions |= parser.HardLineBreak
+	}
+
+	return parser.NewWithExtensions(extensions)
+}
+
+// htmlRenderer returns a new HTML renderer with the flags from a markdown config.
+func htmlRenderer(mdConfig config.MarkdownConfig) *html.Renderer {
+	flags := html.FlagsNone
+	if mdConfig.Smartypants {
+		flags |= html.Smartypants | html.SmartypantsFractions | html.SmartypantsDashes |
+			html.SmartypantsLatexDashes
+	}
+	if mdConfig.HrefTargetBlank {
+		flags |= html.HrefTargetBlank
+	}
+	if mdConfig.NofollowLinks {
+		flags |= html.NofollowLinks
+	}
+	if mdConfig.SkipHtml {
+		flags |= html.SkipHTML
+	}
+	if mdConfig.FootnoteReturnLinks {
+		flags |= html.FootnoteReturnLinks
+	}
+
+	return html.NewRenderer(html.RendererOptions{Flags: flags})
+}
+
+// markdownConfig returns the markdown config for the post.
+// The markdown metadata key overrides options from the markdown config.
+func (p *Post) markdownConfig() (config.MarkdownConfig, error) {
+	if p.metadata == nil {
+		return config.Values.Markdown, nil
+	}
+
+	return config.Values.Markdown.Override(p.metadata.markdown)
+}
@@ -0,0 +1,51 @@
+package posts
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cswilson90/tribo/internal/config"
+)
+
+const extensionsMarkdown = "# Title\n\nA footnote[^1] and \"quotes\" on 2^10^ lines\nwith a [link](https://example.com).\n\n[^1]: The footnote.\n"
+
+func TestMarkdownConfig(t *testing.T) {
+	config.Init([]string{})
+	assert := assert.New(t)
+
+	// Footnotes and superscript are off by default, smartypants is on
+	content := renderMarkdown(parseMarkdown([]byte(extensionsMarkdown), config.Values.Markdown), renderPost, config.Values.Markdown)
+	assert.NotContains(content, `class="footnotes"`, "Footnotes rendered when disabled")
+	assert.NotContains(content, "<sup>10</sup>", "Superscript rendered when disabled")
+	assert.Contains(content, "&ldquo;quotes&rdquo;", "Quotes not converted by smartypants")
+	assert.NotContains(content, "<br", "Hard line break added when disabled")
+
+	config.Values.Markdown.Footnotes = true
+	config.Values.Markdown.Superscript = true
+	config.Values.Markdown.HardLineBreaks = true
+	config.Values.Markdown.Smartypants = false
+	config.Values.Markdown.HrefTargetBlank = true
+	content = renderMarkdown(parseMarkdown([]byte(extensionsMarkdown), config.Values.Markdown), renderPost, config.Values.Markdown)
+	assert.Contains(content, `class="footnotes"`, "Footnotes not rendered when enabled")
+	assert.Contains(content, "<sup>10</sup>", "Superscript not rendered when enabled")
+	assert.Contains(content, "&quot;quotes&quot;", "Quotes converted when smartypants is disabled")
+	assert.Contains(content, "<br", "No hard line break when enabled")
+	assert.Contains(content, `target="_blank"`, "Link doesn't open in a new tab")
+
+	// Metadata overrides the config for a single post
+	config.Init([]string{})
+	post := &Post{metadata: &PostMetadata{markdown: map[string]bool{"footnotes": true, "smartypants": false}}}
+	mdConfig, err := post.markdownConfig()
+	if err != nil {
+		t.Fatalf("Failed to get markdown config for post: %v", err.Error())
+	}
+	assert.True(mdConfig.Footnotes, "Footnotes not enabled by metadata")
+	assert.False(mdConfig.Smartypants, "Smartypants not disabled by metadata")
+	assert.True(mdConfig.Tables, "Option not set in metadata changed")
+	assert.False(config.Values.Markdown.Footnotes, "Metadata changed the markdown config")
+
+	post.metadata.markdown = map[string]bool{"nosuchoption": true}
+	_, err = post.markdownConfig()
+	assert.Error(err, "Expected error for unknown markdown option in metadata")
+}
@@ -1,14 +1,18 @@
 package posts
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math"
 	"path/filepath"
 	"regexp"
 	"sort"
+	"strings"
 	"time"
 
+	"github.com/BurntSushi/toml"
 	log "github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v2"
 )
@@ -16,20 +20,66 @@ import (
 // dateFormat specifies the expected format of publishDates in the metadata.
 const dateFormat = "2006-01-02"
 
+const (
+	// yamlFrontMatter is the line used to open and close YAML front matter.
+	yamlFrontMatter = "---"
+	// tomlFrontMatter is the line used to open and close TOML front matter.
+	tomlFrontMatter = "+++"
+)
+
+// maxInt and minInt are the limits of the int type which numbers in the metadata are converted to.
+const (
+	maxInt = int(^uint(0) >> 1)
+	minInt = -maxInt - 1
+)
+
 var metadataMatch = regexp.MustCompile(`^metadata\.(json|ya?ml)$`)
 
+// knownMetadataKeys are the lowercased metadata keys used by Tribo.
+// Any other keys are added to the params of the post.
+var knownMetadataKeys = map[string]bool{
+	"linkname":    true,
+	"publishdate": true,
+	"updatedate":  true,
+	"tags":        true,
+	"draft":       true,
+	"toc":         true,
+	"summary":     true,
+	"markdown":    true,
+}
+
 // PostMetadata stores the metadata about a post.
 type PostMetadata struct {
 	linkName    string
 	publishDate time.Time
-	tags        []string
+	// updateDate is the date the post was last updated.
+	// It's the zero time if no update date has been given.
+	updateDate time.Time
+	tags       []string
+	// draft marks a post as unfinished so it's only published if drafts are enabled.
+	draft bool
+	// toc turns the table of contents on or off for the post overriding the toc config option.
+	// It's nil if the metadata doesn't set it.
+	toc *bool
+	// summary is markdown used as the preview of the post instead of the content.
+	summary string
+	// markdown overrides options from the markdown config for the post.
+	markdown map[string]bool
+	// params contains all the keys in the metadata that aren't used by Tribo.
+	// They are passed to the templates so themes can use custom metadata.
+	params map[string]interface{}
 }
 
 // rawPostMetaData defines the structure of metadata in the config file.
 type rawPostMetadata struct {
 	LinkName    string
 	PublishDate string
+	UpdateDate  string
 	Tags        []string
+	Draft       bool
+	Toc         *bool
+	Summary     string
+	Markdown    map[string]bool
 }
 
 // isMetadataFile returns true if a file is a metadata file.
@@ -68,10 +118,14 @@ func parseMetadata(dir string) (*PostMetadata, error) {
 	}
 
 	rawMetadata := &rawPostMetadata{}
+	var params map[string]interface{}
 	if fileExt == ".json" {
 		err = json.Unmarshal(data, rawMetadata)
+		if err == nil {
+			err = json.Unmarshal(data, &params)
+		}
 	} else if fileExt == ".yaml" || fileExt == ".yml" {
-		err = yaml.Unmarshal(data, rawMetadata)
+		params, err = unmarshalYAMLMetadata(data, rawMetadata)
 	} else {
 		log.Fatalf("Got unknown metadata file extension '%v'", fileExt)
 	}
@@ -80,7 +134,7 @@ func parseMetadata(dir string) (*PostMetadata, error) {
 		return nil, fmt.Errorf("Failed to parse metadata '%v': "+err.Error(), fullPath)
 	}
 
-	metadata, err := processRawMetadata(rawMetadata)
+	metadata, err := processRawMetadata(rawMetadata, params)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to parse metadata '%v': "+err.Error(), fullPath)
 	}
@@ -88,8 +142,178 @@ func parseMetadata(dir string) (*PostMetadata, error) {
 	return metadata, nil
 }
 
+// hasFrontMatter returns true if the content of a markdown file starts with front matter.
+func hasFrontMatter(content []byte) bool {
+	_, _, _, found := splitFrontMatter(content)
+	return found
+}
+
+// parseFrontMatter parses the metadata from the front matter at the top of a markdown file.
+// The content with the front matter removed is returned along with the metadata.
+// Front matter can be YAML surrounded by "---" lines or TOML surrounded by "+++" lines.
+func parseFrontMatter(content []byte) (*PostMetadata, []byte, error) {
+	frontMatter, delimiter, body, found := splitFrontMatter(content)
+	if !found {
+		return nil, content, fmt.Errorf("No front matter found")
+	}
+
+	var err error
+	rawMetadata := &rawPostMetadata{}
+	var params map[string]interface{}
+	if delimiter == tomlFrontMatter {
+		_, err = toml.Decode(string(frontMatter), rawMetadata)
+		if err == nil {
+			_, err = toml.Decode(string(frontMatter), &params)
+		}
+	} else {
+		params, err = unmarshalYAMLMetadata(frontMatter, rawMetadata)
+	}
+
+	if err != nil {
+		return nil, body, fmt.Errorf("Failed to parse front matter: " + err.Error())
+	}
+
+	metadata, err := processRawMetadata(rawMetadata, params)
+	if err != nil {
+		return nil, body, fmt.Errorf("Failed to parse front matter: " + err.Error())
+	}
+
+	return metadata, body, nil
+}
+
+// stripFrontMatter returns the content of a markdown file with any front matter removed.
+// It's used for posts with a metadata file so the front matter is only removed if it parses
+// as metadata. Otherwise content which starts with a "---" horizontal rule would lose
+// everything up to the next "---" line.
+func stripFrontMatter(content []byte) []byte {
+	frontMatter, delimiter, body, found := splitFrontMatter(content)
+	if !found {
+		return content
+	}
+
+	var keys map[string]interface{}
+	var err error
+	if delimiter == tomlFrontMatter {
+		_, err = toml.Decode(string(frontMatter), &keys)
+	} else {
+		err = yaml.Unmarshal(frontMatter, &keys)
+	}
+	if err != nil || len(keys) == 0 {
+		return content
+	}
+
+	return body
+}
+
+// splitFrontMatter splits the front matter from the rest of the content of a markdown file.
+// The front matter must start on the first line of the file and be closed by the same
+// delimiter it was opened with. If there isn't any front matter found is false and the
+// body is the whole of the content.
+func splitFrontMatter(content []byte) (frontMatter []byte, delimiter string, body []byte, found bool) {
+	lines := bytes.SplitAfter(content, []byte("\n"))
+	if len(lines) == 0 {
+		return nil, "", content, false
+	}
+
+	delimiter = string(bytes.TrimSpace(lines[0]))
+	if delimiter != yamlFrontMatter && delimiter != tomlFrontMatter {
+		return nil, "", content, false
+	}
+
+	offset := len(lines[0])
+	for _, line := range lines[1:] {
+		if string(bytes.TrimSpace(line)) == delimiter {
+			frontMatter = content[len(lines[0]):offset]
+			body = content[offset+len(line):]
+			return frontMatter, delimiter, body, true
+		}
+		offset += len(line)
+	}
+
+	// No closing delimiter so this isn't front matter
+	return nil, "", content, false
+}
+
+// unmarshalYAMLMetadata decodes YAML metadata into the raw metadata and also returns all
+// the keys in the metadata as a map.
+func unmarshalYAMLMetadata(data []byte, rawMetadata *rawPostMetadata) (map[string]interface{}, error) {
+	err := yaml.Unmarshal(data, rawMetadata)
+	if err != nil {
+		return nil, err
+	}
+
+	var params map[string]interface{}
+	err = yaml.Unmarshal(data, &params)
+	return params, err
+}
+
+// metadataParams returns the metadata keys which aren't used by Tribo.
+// Keys are compared case insensitively as the metadata formats match struct fields that way.
+func metadataParams(allKeys map[string]interface{}) map[string]interface{} {
+	params := make(map[string]interface{})
+	for key, value := range allKeys {
+		if !knownMetadataKeys[strings.ToLower(key)] {
+			params[key] = normaliseParam(value)
+		}
+	}
+
+	return params
+}
+
+// normaliseParam converts the maps decoded from YAML, which have interface{} keys, into maps
+// with string keys so all metadata formats give the same types to the templates.
+// Numbers are also converted as JSON decodes them all as float64, YAML as int and TOML as int64.
+// Whole numbers become an int and any other number a float64.
+func normaliseParam(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int64:
+		if v >= int64(minInt) && v <= int64(maxInt) {
+			return int(v)
+		}
+	case uint64:
+		if v <= uint64(maxInt) {
+			return int(v)
+		}
+	case float64:
+		if v == math.Trunc(v) && v >= float64(minInt) && v < float64(maxInt) {
+			return int(v)
+		}
+	case map[interface{}]interface{}:
+		normalised := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			normalised[fmt.Sprint(key)] = normaliseParam(item)
+		}
+		return normalised
+	case map[string]interface{}:
+		normalised := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			normalised[key] = normaliseParam(item)
+		}
+		return normalised
+	case []interface{}:
+		normalised := make([]interface{}, len(v))
+		for i, item := range v {
+			normalised[i] = normaliseParam(item)
+		}
+		return normalised
+	}
+
+	return value
+}
+
+// lastUpdated returns the date the post was last changed.
+// This is the update date if one was given otherwise the publish date.
+func (m *PostMetadata) lastUpdated() time.Time {
+	if m.updateDate.After(m.publishDate) {
+		return m.updateDate
+	}
+
+	return m.publishDate
+}
+
 // processRawMetadata converts the raw data to the right types and does validation.
-func processRawMetadata(rawData *rawPostMetadata) (*PostMetadata, error) {
+// allKeys contains every key in the metadata and is used to find the custom params.
+func processRawMetadata(rawData *rawPostMetadata, allKeys map[string]interface{}) (*PostMetadata, error) {
 	if rawData.PublishDate == "" {
 		return nil, fmt.Errorf("No publish date given for post")
 	}
@@ -99,12 +323,26 @@ func processRawMetadata(rawData *rawPostMetadata) (*PostMetadata, error) {
 		return nil, fmt.Errorf("Could not parse publish date '%v': "+err.Error(), rawData.PublishDate)
 	}
 
+	var updateTime time.Time
+	if rawData.UpdateDate != "" {
+		updateTime, err = time.Parse(dateFormat, rawData.UpdateDate)
+		if err != nil {
+			return nil, fmt.Errorf("Could not parse update date '%v': "+err.Error(), rawData.UpdateDate)
+		}
+	}
+
 	// Sort tags
 	sort.Strings(rawData.Tags)
 
 	return &PostMetadata{
 		linkName:    rawData.LinkName,
 		publishDate: publishTime,
+		updateDate:  updateTime,
 		tags:        rawData.Tags,
+		draft:       rawData.Draft,
+		toc:         rawData.Toc,
+		summary:     strings.TrimSpace(rawData.Summary),
+		markdown:    rawData.Markdown,
+		params:      metadataParams(allKeys),
 	}, nil
 }
@@ -2,37 +2,64 @@ package posts
 
 import (
 	"fmt"
+	"io/ioutil"
 	"testing"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
 
+// tocOn and tocOff are used to check the toc metadata key which is nil if it isn't set.
+var tocOn, tocOff = true, false
+
 var tests = []struct {
 	dir      string
 	title    string
 	linkName string
 	date     string
+	updated  string
 	tags     []string
+	draft    bool
+	toc      *bool
+	summary  string
+	markdown map[string]bool
+	params   map[string]interface{}
 }{
 	{
 		dir:      "testdata/posts/2021/01/post1/",
 		linkName: "",
 		date:     "2021-01-24",
+		updated:  "2021-02-01",
 		tags:     []string{"happy", "upbeat"},
+		params: map[string]interface{}{
+			"hero_image": "cat.jpg",
+			"score":      4.5,
+			"social":     map[string]interface{}{"twitter": "@test", "rating": 5},
+		},
 	},
 	{
 		dir:      "testdata/posts/2021/01/post2/",
 		linkName: "post2-2021-01",
 		date:     "2021-01-01",
 		tags:     []string{"jolly"},
+		toc:      &tocOn,
+		params:   map[string]interface{}{"canonical": "https://example.com/post2", "rating": 5, "score": 4.5},
 	},
 	{
 		dir:      "testdata/posts/2020/12/post2/",
 		linkName: "Post 2 2020/12",
 		date:     "2020-12-04",
+		summary:  "A post from *December*.",
+		markdown: map[string]bool{"footnotes": true},
 		tags:     nil,
 	},
+	{
+		dir:      "testdata/posts/2021/02/draft/",
+		linkName: "",
+		date:     "2021-02-10",
+		tags:     []string{"unfinished"},
+		draft:    true,
+	},
 }
 
 var errorTests = []struct {
@@ -43,6 +70,7 @@ var errorTests = []struct {
 	{"testdata/posts/errors/invalid-yaml/"},
 	{"testdata/posts/errors/invalid-json/"},
 	{"testdata/posts/errors/invalid-date/"},
+	{"testdata/posts/errors/no-front-matter/"},
 }
 
 func TestMetadata(t *testing.T) {
@@ -61,7 +89,21 @@ func TestMetadata(t *testing.T) {
 
 			assert.Equal(tc.linkName, metaData.linkName, "Link name incorrect")
 			assert.Equal(tc.date, metaData.publishDate.Format(dateFormat), "Date incorrect")
+			if tc.updated == "" {
+				assert.True(metaData.updateDate.IsZero(), "Update date incorrect")
+			} else {
+				assert.Equal(tc.updated, metaData.updateDate.Format(dateFormat), "Update date incorrect")
+			}
 			assert.Equal(tc.tags, metaData.tags, "Tags incorrect")
+			assert.Equal(tc.draft, metaData.draft, "Draft incorrect")
+			assert.Equal(tc.toc, metaData.toc, "Toc incorrect")
+			assert.Equal(tc.summary, metaData.summary, "Summary incorrect")
+			assert.Equal(tc.markdown, metaData.markdown, "Markdown options incorrect")
+			if tc.params == nil {
+				assert.Empty(metaData.params, "Params incorrect")
+			} else {
+				assert.Equal(tc.params, metaData.params, "Params incorrect")
+			}
 		})
 	}
 
@@ -77,3 +119,99 @@ func TestMetadata(t *testing.T) {
 		})
 	}
 }
+
+var frontMatterTests = []struct {
+	file     string
+	linkName string
+	date     string
+	tags     []string
+	toc      *bool
+	markdown map[string]bool
+	params   map[string]interface{}
+	body     string
+}{
+	{
+		file:     "testdata/posts/2021/03/front-matter/content.md",
+		linkName: "",
+		date:     "2021-03-02",
+		tags:     []string{"yaml"},
+		body:     "# 2021 03 Front Matter\n\nContent\n",
+	},
+	{
+		file:     "testdata/posts/2021/single-post.md",
+		linkName: "single-post",
+		date:     "2021-04-05",
+		tags:     []string{"toml"},
+		toc:      &tocOff,
+		markdown: map[string]bool{"smartypants": false},
+		params:   map[string]interface{}{"canonical": "https://example.com/single-post", "rating": 5, "score": 4.5},
+		body:     "# 2021 04 Single Post\n\nContent\n",
+	},
+}
+
+var frontMatterErrorTests = []struct {
+	content string
+}{
+	{"# No Front Matter\n\nContent\n"},
+	{"---\npublishdate: \"2021-03-02\"\n# Unclosed\n"},
+	{"---\ntags: [\n---\n# Invalid YAML\n"},
+	{"+++\npublishdate = \n+++\n# Invalid TOML\n"},
+	{"---\ntags:\n  - nodate\n---\n# No Date\n"},
+}
+
+func TestFrontMatter(t *testing.T) {
+	log.SetLevel(log.FatalLevel)
+	assert := assert.New(t)
+
+	for i, tc := range frontMatterTests {
+		content, err := ioutil.ReadFile(tc.file)
+		if err != nil {
+			t.Fatalf("Failed to read '%v': "+err.Error(), tc.file)
+		}
+
+		metaData, body, err := parseFrontMatter(content)
+		if err != nil {
+			t.Errorf("Couldn't load front matter for test %v: "+err.Error(), i)
+			continue
+		}
+
+		assert.Equal(tc.linkName, metaData.linkName, "Link name incorrect")
+		assert.Equal(tc.date, metaData.publishDate.Format(dateFormat), "Date incorrect")
+		assert.Equal(tc.tags, metaData.tags, "Tags incorrect")
+		assert.Equal(tc.toc, metaData.toc, "Toc incorrect")
+		assert.Equal(tc.markdown, metaData.markdown, "Markdown options incorrect")
+		if tc.params == nil {
+			assert.Empty(metaData.params, "Params incorrect")
+		} else {
+			assert.Equal(tc.params, metaData.params, "Params incorrect")
+		}
+		assert.Equal(tc.body, string(body), "Body incorrect")
+	}
+
+	for i, tc := range frontMatterErrorTests {
+		_, _, err := parseFrontMatter([]byte(tc.content))
+		if err == nil {
+			t.Errorf("Expected error front matter test %v", i)
+		}
+	}
+}
+
+var stripFrontMatterTests = []struct {
+	content  string
+	stripped string
+}{
+	{"---\ntags: [a]\n---\n# Title\n", "# Title\n"},
+	{"+++\ntags = [\"a\"]\n+++\n# Title\n", "# Title\n"},
+	// Horizontal rules aren't front matter
+	{"---\n\nSome text.\n\n---\n\nMore text.\n", "---\n\nSome text.\n\n---\n\nMore text.\n"},
+	{"---\n---\n# Title\n", "---\n---\n# Title\n"},
+	{"# Title\n\n---\n", "# Title\n\n---\n"},
+}
+
+func TestStripFrontMatter(t *testing.T) {
+	assert := assert.New(t)
+
+	for i, tc := range stripFrontMatterTests {
+		assert.Equal(tc.stripped, string(stripFrontMatter([]byte(tc.content))), "Incorrect content for test %v", i)
+	}
+}
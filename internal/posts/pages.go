@@ -0,0 +1,353 @@
+package posts
+
+import (
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/otiai10/copy"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+
+	"github.com/cswilson90/tribo/internal/config"
+)
+
+// pageContentFile is the name of the markdown file in a page directory.
+const pageContentFile = "content.md"
+
+// sitePages stores the pages found in the current build.
+// It's used to add the pages to the template data for navigation menus.
+var sitePages []*Page
+
+// pageDirs keeps track of the output directories of the pages built in the current build so
+// the output of pages which have been removed or moved can be removed.
+var pageDirs = make(DirSet)
+
+/*
+	Page is a standalone page of the blog such as an about page.
+
+	Pages are found in the pages directory and are output to a path mirroring their
+	location e.g. "pages/about/content.md" or "pages/about.md" is output to "/about/".
+	Unlike posts pages don't have a publish date.
+*/
+type Page struct {
+	// dir is the input directory of the page or the markdown file for single file pages.
+	dir         string
+	contentFile string
+	// resourceDir is the location of the directory containing static resources for the page.
+	resourceDir string
+	// relPath is the path of the page relative to the root of the blog using "/" as the separator.
+	relPath string
+	// sourcePath is the path of dir relative to the pages directory using "/" as the separator.
+	sourcePath string
+
+	urlPath   string
+	outputDir string
+
+	title   string
+	content string
+	// weight controls the order of pages in navigation menus. Pages with a lower weight
+	// come first, then pages without a weight. Pages with the same weight are ordered by title.
+	weight int
+	// params contains all the keys in the front matter that aren't used by Tribo.
+	params map[string]interface{}
+}
+
+// pageData contains the template data for a single page.
+type pageData struct {
+	Title   string
+	Content template.HTML
+	// Url is the URL used to link to the page.
+	Url string
+	// Params contains any keys in the page's front matter which aren't used by Tribo.
+	Params map[string]interface{}
+	// SourcePath is the path of the page's directory, or file, relative to the pages directory.
+	SourcePath string
+}
+
+// pageLinkData contains the template data used to link to a page in a navigation menu.
+type pageLinkData struct {
+	Title string
+	Url   string
+}
+
+// pagePageData contains all the template data for rendering a standalone page.
+type pagePageData struct {
+	Common commonData
+	Page   pageData
+}
+
+// loadPages finds all the pages in the pages directory and reads their content.
+// Pages which can't be read are logged and left out.
+// The pages are sorted in the order used for navigation menus.
+func loadPages(pagesDir string) []*Page {
+	pages := make([]*Page, 0)
+	if !fileExists(pagesDir) {
+		log.Debugf("Pages directory '%v' doesn't exist, not adding any pages", pagesDir)
+		return pages
+	}
+
+	for _, page := range findPages(pagesDir) {
+		err := page.load()
+		if err != nil {
+			log.Errorf("Error loading page in '%v': "+err.Error(), page.dir)
+			continue
+		}
+		pages = append(pages, page)
+	}
+
+	// Pages without a weight come after pages with one
+	sort.SliceStable(pages, func(i, j int) bool {
+		wi, wj := pages[i].weight, pages[j].weight
+		if wi != wj {
+			return wj == 0 || (wi != 0 && wi < wj)
+		}
+		return pages[i].title < pages[j].title
+	})
+
+	return pages
+}
+
+// findPages recursively searches a directory for pages.
+// Any directory with a "content.md" file is a page directory and any other markdown file
+// is a single file page.
+func findPages(pagesDir string) []*Page {
+	log.Infof("Looking for pages recursively in '%v'", pagesDir)
+
+	pages := make([]*Page, 0)
+	filepath.Walk(pagesDir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			log.Warnf("Could not read '%v': "+err.Error(), file)
+			return nil
+		}
+
+		// Files in a page's resources directory aren't pages
+		if info.IsDir() {
+			if info.Name() == "resources" && fileExists(filepath.Join(filepath.Dir(file), pageContentFile)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(file) != ".md" {
+			return nil
+		}
+
+		// The output path of the page is the page directory or the file without the extension
+		page := &Page{contentFile: file}
+		outputPath := strings.TrimSuffix(file, filepath.Ext(file))
+		if info.Name() == pageContentFile {
+			page.dir = filepath.Dir(file)
+			outputPath = page.dir
+			if fileExists(filepath.Join(page.dir, "resources")) {
+				page.resourceDir = filepath.Join(page.dir, "resources")
+			}
+		} else {
+			page.dir = file
+		}
+
+		relPath, err := filepath.Rel(pagesDir, outputPath)
+		if err != nil {
+			return nil
+		}
+		page.relPath = filepath.ToSlash(relPath)
+
+		sourcePath, err := filepath.Rel(pagesDir, page.dir)
+		if err != nil {
+			return nil
+		}
+		page.sourcePath = filepath.ToSlash(sourcePath)
+
+		if page.relPath == "." {
+			log.Errorf("Page '%v' would replace the post list, skipping", file)
+			return nil
+		}
+		if reservedPath(page.relPath) {
+			log.Errorf("Page '%v' would clash with the output for posts, tags or archives, skipping", file)
+			return nil
+		}
+
+		pages = append(pages, page)
+		return nil
+	})
+
+	log.Infof("Found %v pages in '%v'", len(pages), pagesDir)
+	return pages
+}
+
+// reservedPath returns true if a path in the output is used for posts, tags, archives or the post list.
+// Pages can't be output to these paths as they would be removed when cleaning up the output.
+func reservedPath(relPath string) bool {
+	topDir := strings.SplitN(relPath, "/", 2)[0]
+	return topDir == tagsDirName || topDir == listPageDirName || topDir == archiveDirName ||
+		looksLikeYear.MatchString(topDir)
+}
+
+// load reads the front matter and content of a page and renders the markdown.
+func (p *Page) load() error {
+	mdContent, err := ioutil.ReadFile(p.contentFile)
+	if err != nil {
+		return err
+	}
+
+	frontMatter, delimiter, body, found := splitFrontMatter(mdContent)
+	if found {
+		err = p.parseFrontMatter(frontMatter, delimiter)
+		if err != nil {
+			return err
+		}
+		mdContent = body
+	}
+
+	doc := parseMarkdown(mdContent, config.Values.Markdown)
+	p.title = renderMarkdown(doc, renderTitle, config.Values.Markdown)
+	if p.title == "" {
+		p.title = filepath.Base(p.relPath)
+	}
+	p.content = renderMarkdown(doc, renderPost, config.Values.Markdown)
+	p.urlPath = config.Values.BaseUrlPath + "/" + p.relPath + "/"
+
+	return nil
+}
+
+// parseFrontMatter parses the optional front matter of a page.
+// The only key used by Tribo is "weight", all other keys are stored as params.
+func (p *Page) parseFrontMatter(frontMatter []byte, delimiter string) error {
+	var err error
+	allKeys := make(map[string]interface{})
+	if delimiter == tomlFrontMatter {
+		_, err = toml.Decode(string(frontMatter), &allKeys)
+	} else {
+		err = yaml.Unmarshal(frontMatter, &allKeys)
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to parse front matter: " + err.Error())
+	}
+
+	p.params = make(map[string]interface{})
+	for key, value := range allKeys {
+		if strings.ToLower(key) != "weight" {
+			p.params[key] = normaliseParam(value)
+			continue
+		}
+
+		switch weight := value.(type) {
+		case int:
+			p.weight = weight
+		case int64:
+			p.weight = int(weight)
+		default:
+			return fmt.Errorf("Page weight '%v' isn't an integer", value)
+		}
+	}
+
+	return nil
+}
+
+// build outputs the HTML for a page using the "page.html.tmpl" template.
+// The page is saved in "<relPath>/index.html" in the output directory.
+func (p *Page) build(outputDir string) error {
+	p.outputDir = filepath.Join(outputDir, filepath.FromSlash(p.relPath))
+	pageDirs[p.outputDir] = true
+	currentManifest.recordPage(p.relPath)
+
+	err := os.MkdirAll(p.outputDir, 0775)
+	if err != nil {
+		return err
+	}
+
+	if p.resourceDir != "" {
+		log.Debugf("Copying page resources from '%v'", p.resourceDir)
+		err = copy.Copy(p.resourceDir, p.outputDir)
+		if err != nil {
+			log.Errorf("Failed to copy resource files from '%v' to '%v':"+err.Error(), p.resourceDir, p.outputDir)
+		}
+	}
+
+	tmplData := pagePageData{
+		Common: comData(),
+		Page: pageData{
+			Title:      p.title,
+			Content:    template.HTML(p.content),
+			Url:        p.urlPath,
+			Params:     p.params,
+			SourcePath: p.sourcePath,
+		},
+	}
+	tmplData.Common.PageTitle = p.title + " - " + config.Values.BlogName
+
+	return renderTemplate("page.html.tmpl", filepath.Join(p.outputDir, "index.html"), tmplData)
+}
+
+// buildPages outputs the HTML for all the pages.
+// Errors building individual pages are logged and the page is left out.
+func buildPages(pages []*Page, outputDir string) {
+	pageDirs = make(DirSet)
+
+	for _, page := range pages {
+		err := page.build(outputDir)
+		if err != nil {
+			log.Errorf("Error building page in '%v': "+err.Error(), page.dir)
+		}
+	}
+}
+
+// removeExtraPageDirs removes the output of pages from the last build which weren't built in
+// the current build. If a page from the current build or any static files are inside the
+// directory of an old page only the old page's index.html is removed.
+func removeExtraPageDirs(outputDir string) {
+	for _, relPath := range lastManifest.Pages {
+		pageDir := filepath.Join(outputDir, filepath.FromSlash(relPath))
+		if pageDirs[pageDir] {
+			continue
+		}
+
+		if containsPageDir(pageDir) || staticPathExists(relPath) {
+			os.Remove(filepath.Join(pageDir, "index.html"))
+			continue
+		}
+
+		os.RemoveAll(pageDir)
+	}
+}
+
+// containsPageDir returns true if the output directory of a page from the current build is
+// inside a directory.
+func containsPageDir(dir string) bool {
+	for pageDir := range pageDirs {
+		if strings.HasPrefix(pageDir, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// staticPathExists returns true if a path relative to the root of the output exists in any
+// of the static directories.
+func staticPathExists(relPath string) bool {
+	for _, staticDir := range staticDirs() {
+		if fileExists(filepath.Join(staticDir, filepath.FromSlash(relPath))) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// pageLinks returns the template data for linking to all the pages in navigation menus.
+func pageLinks() []pageLinkData {
+	links := make([]pageLinkData, len(sitePages))
+	for i, page := range sitePages {
+		links[i] = pageLinkData{
+			Title: page.title,
+			Url:   page.urlPath,
+		}
+	}
+
+	return links
+}
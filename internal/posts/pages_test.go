@@ -0,0 +1,94 @@
+package posts
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/otiai10/copy"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cswilson90/tribo/internal/config"
+)
+
+const pagesDir = "testdata/pages"
+
+func TestLoadPages(t *testing.T) {
+	config.Init([]string{})
+	log.SetLevel(log.FatalLevel)
+	config.Values.BaseUrlPath = baseUrlPath
+
+	pages := loadPages(pagesDir)
+	if len(pages) != 2 {
+		t.Fatalf("Expected 2 pages got %v", len(pages))
+	}
+
+	assert := assert.New(t)
+
+	// Pages with a weight come first
+	assert.Equal("About Me", pages[0].title, "Incorrect title for page 1")
+	assert.Equal(baseUrlPath+"/about/", pages[0].urlPath, "Incorrect URL for page 1")
+	assert.Equal("about", pages[0].sourcePath, "Incorrect source path for page 1")
+	assert.Equal(1, pages[0].weight, "Incorrect weight for page 1")
+	assert.Equal(map[string]interface{}{"hero_image": "me.jpg"}, pages[0].params, "Incorrect params for page 1")
+	assert.Contains(pages[0].content, "Some information about me.", "Incorrect content for page 1")
+	assert.NotContains(pages[0].content, "About Me", "Title included in content of page 1")
+
+	assert.Equal("Contact", pages[1].title, "Incorrect title for page 2")
+	assert.Equal(baseUrlPath+"/contact/", pages[1].urlPath, "Incorrect URL for page 2")
+	assert.Equal("contact.md", pages[1].sourcePath, "Incorrect source path for page 2")
+}
+
+func TestBuildPages(t *testing.T) {
+	config.Init([]string{})
+	log.SetLevel(log.FatalLevel)
+	config.Values.StaticDir = staticDir
+	config.Values.TemplateDir = templateDir
+	config.Values.PagesDir = pagesDir
+
+	tmpDir := t.TempDir()
+	assert := assert.New(t)
+
+	// Build twice to check cleaning up the output doesn't remove the pages
+	for i := 0; i < 2; i++ {
+		err := BuildPosts(inputDir, tmpDir)
+		if err != nil {
+			t.Fatalf("Failed to build posts: %v", err.Error())
+		}
+
+		expectedFiles := []string{"about/index.html", "about/photo.txt", "contact/index.html"}
+		for _, file := range expectedFiles {
+			assert.FileExists(filepath.Join(tmpDir, file), "Expected page file missing after build %v", i+1)
+		}
+		assert.False(fileExists(filepath.Join(tmpDir, "2021/clash/index.html")), "Page in reserved path was output")
+	}
+
+	// Pages are linked to from every page
+	postHTML, err := ioutil.ReadFile(filepath.Join(tmpDir, "2021/01/2021-01-post-1/index.html"))
+	if err != nil {
+		t.Fatalf("Failed to read post html: %v", err.Error())
+	}
+	assert.Contains(string(postHTML), `<a href="/about/">About Me</a>`, "Post doesn't link to page")
+
+	// The output of a page is removed when the page is removed
+	movedPagesDir := filepath.Join(t.TempDir(), "pages")
+	err = copy.Copy(pagesDir, movedPagesDir)
+	if err != nil {
+		t.Fatalf("Failed to copy pages: %v", err.Error())
+	}
+	err = os.Rename(filepath.Join(movedPagesDir, "about"), filepath.Join(movedPagesDir, "about-me"))
+	if err != nil {
+		t.Fatalf("Failed to move page: %v", err.Error())
+	}
+	config.Values.PagesDir = movedPagesDir
+
+	err = BuildPosts(inputDir, tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to build posts: %v", err.Error())
+	}
+	assert.NoDirExists(filepath.Join(tmpDir, "about"), "Output of moved page not removed")
+	assert.FileExists(filepath.Join(tmpDir, "about-me", "index.html"), "Moved page not output")
+	assert.FileExists(filepath.Join(tmpDir, "contact", "index.html"), "Unchanged page removed")
+}
@@ -6,6 +6,7 @@
 package posts
 
 import (
+	"bytes"
 	"container/list"
 	"fmt"
 	"io"
@@ -55,32 +56,56 @@ var (
 	// Regexes for checking if string looks like year or month
 	looksLikeYear  = regexp.MustCompile(`^\d{4}$`)
 	looksLikeMonth = regexp.MustCompile(`^\d{2}$`)
+
+	// moreMarker matches the HTML comment used to mark the end of the preview of a post
+	moreMarker = regexp.MustCompile(`^<!--\s*more\s*-->$`)
 )
 
 // Post is a structure that contains all teh information about a single post.
 type Post struct {
 	// dir is the input directory that the post is created from.
+	// For single file posts it's the markdown file of the post.
 	dir       string
 	outputDir string
+	// sourcePath is the path of dir relative to the posts directory using "/" as the separator.
+	sourcePath string
 	// contentFile is the location of the markdown file with post content.
 	contentFile string
 	// resourceDir is the location of the directory containing static resources for the post.
 	resourceDir string
+	// frontMatter is true if the metadata of the post is in front matter at the top of the
+	// content file rather than in a separate metadata file.
+	frontMatter bool
 
 	// urlPath is the path that links to the post on the web server.
 	urlPath  string
 	metadata *PostMetadata
 
+	// doc is the parsed markdown of the post which the content, preview and title are
+	// rendered from. It's nil if the post was unchanged and its content came from the manifest.
+	doc     ast.Node
 	content string
 	preview string
 	title   string
+	// toc is the table of contents of the post. It's nil unless the post has one enabled.
+	toc []tocEntry
 	// linkName is used when creating the path of the post e.g. if a post was published
 	// in April 2021 and had a linkName of "test-post" the URL path would be "2021/04/test-post".
 	linkName string
 
+	// inputHash is a hash of all the files in the post's input directory.
+	// It's used to check if the post has changed since the last build.
+	inputHash string
+	// neighboursHash is a hash of the inputs of the previous and next posts.
+	// The post page links to them so it needs rendering again when they change.
+	neighboursHash string
+	// lastBuild is the manifest entry from the last build if the post's output can be reused.
+	// The post page is still rendered again if its neighbours have changed.
+	lastBuild *manifestPost
+
 	// published indicates whether the post has been included in the output.
-	// A post won't be included if their publishDate is in the future or there is a problem
-	// building the post.
+	// A post won't be included if their publishDate is in the future, it's a draft or there
+	// is a problem building the post.
 	published bool
 }
 
@@ -97,6 +122,13 @@ type postRenderer struct {
 	// Should be set to renderPost, renderPreview or renderTitle
 	mode renderMode
 
+	// moreMarker is the <!--more--> comment which ends the preview of the post.
+	// It's nil if the post doesn't have one.
+	moreMarker ast.Node
+	// previewEnd is the paragraph containing the more marker if it's inline with other text.
+	// The preview ends after the paragraph.
+	previewEnd ast.Node
+
 	rendering bool
 	seenTitle bool
 }
@@ -114,22 +146,50 @@ func (p Posts) Less(i, j int) bool {
 
 	inputDir is the directory where the content of the posts can be found and
 	outputDir is where to output the static content of the blog.
+
+	Errors building individual posts are logged and the post is left out of the
+	output. An error is only returned if the blog as a whole can't be built.
 */
-func BuildPosts(inputDir, outputDir string) {
+func BuildPosts(inputDir, outputDir string) error {
 	absInputDir, err := filepath.Abs(inputDir)
 	if err != nil {
-		log.Fatalf("Failed to absolute path of dir '%v': "+err.Error(), inputDir)
+		return fmt.Errorf("Failed to absolute path of dir '%v': "+err.Error(), inputDir)
 	}
 	absOutputDir, err := filepath.Abs(outputDir)
 	if err != nil {
-		log.Fatalf("Failed to absolute path of dir '%v': "+err.Error(), outputDir)
+		return fmt.Errorf("Failed to absolute path of dir '%v': "+err.Error(), outputDir)
+	}
+
+	err = checkTheme()
+	if err != nil {
+		return err
 	}
 
 	err = initTemplates()
 	if err != nil {
-		log.Fatalf("Failed to parse post templates: " + err.Error())
+		return fmt.Errorf("Failed to parse post templates: " + err.Error())
 	}
 
+	err = initHighlighting()
+	if err != nil {
+		return err
+	}
+
+	// Pages are loaded before building posts so every page can link to them
+	sitePages = loadPages(config.Values.PagesDir)
+
+	globalHash, err := globalInputsHash()
+	if err != nil {
+		return fmt.Errorf("Failed to hash config and templates: " + err.Error())
+	}
+	lastManifest = loadManifest(absOutputDir, globalHash)
+	currentManifest = newBuildManifest(globalHash)
+
+	uniqueDirsLock.Lock()
+	uniqueDirs = make(map[string]*Post)
+	uniqueDirsLock.Unlock()
+	listPages = make([]listPage, 0)
+
 	posts := findPosts(absInputDir)
 
 	// Build posts in parallel
@@ -152,13 +212,23 @@ func BuildPosts(inputDir, outputDir string) {
 	close(postJobs)
 	wg.Wait()
 
-	// Copy static files to output dir
-	log.Infof("Copying static files from '%v' to '%v'", config.Values.StaticDir, absOutputDir)
-	err = copy.Copy(config.Values.StaticDir, absOutputDir)
-	if err != nil {
-		log.Fatalf("Failed to copy static files from '%v' to '%v':"+err.Error(), config.Values.StaticDir, absOutputDir)
+	// Copy static files to output dir with the theme's files first so the blog's files replace them
+	for _, staticDir := range staticDirs() {
+		// The static directories are optional when using a theme
+		if config.Values.Theme != "" && !fileExists(staticDir) {
+			continue
+		}
+
+		log.Infof("Copying static files from '%v' to '%v'", staticDir, absOutputDir)
+		err = copy.Copy(staticDir, absOutputDir)
+		if err != nil {
+			return fmt.Errorf("Failed to copy static files from '%v' to '%v': "+err.Error(), staticDir, absOutputDir)
+		}
 	}
 
+	// Output standalone pages
+	buildPages(sitePages, absOutputDir)
+
 	// Filter out unpublished posts
 	publishedPosts := make(Posts, 0)
 	for _, post := range posts {
@@ -169,19 +239,66 @@ func BuildPosts(inputDir, outputDir string) {
 
 	sort.Sort(publishedPosts)
 
-	// Remove directories from output that don't have a published post
-	err = removeExtraOutputDirs(outputDir)
+	// Output the page of each post now its previous and next posts are known
+	renderPosts(publishedPosts, config.Values.Parallelism)
+
+	// Output list of posts HTML
+	err = postListHTML(publishedPosts, absOutputDir)
 	if err != nil {
-		log.Errorf("Failed to clean up non-existent posts from output directory: %v", err.Error())
+		log.Errorf("Failed to write post list: " + err.Error())
 	}
 
-	// Output list of posts HTML
-	indexFile := filepath.Join(absOutputDir, "index.html")
-	postListHTML(publishedPosts, indexFile)
+	// Output a page and RSS feed for each tag and a list of all tags
+	err = tagOutput(publishedPosts, absOutputDir)
+	if err != nil {
+		log.Errorf("Failed to write tag pages: " + err.Error())
+	}
+
+	// Output an archive page for each year and month and an archive of all posts
+	err = archiveOutput(publishedPosts, absOutputDir)
+	if err != nil {
+		log.Errorf("Failed to write archive pages: " + err.Error())
+	}
+
+	// Remove directories from output that don't have a published post, tag or archive
+	if !config.Values.NoOutputCleanup {
+		err = removeExtraOutputDirs(absOutputDir)
+		if err != nil {
+			log.Errorf("Failed to clean up non-existent posts from output directory: %v", err.Error())
+		}
+	}
+
+	// Output the stylesheet for highlighted code if it uses CSS classes
+	err = highlightCSS(filepath.Join(absOutputDir, highlightCSSFile))
+	if err != nil {
+		log.Errorf("Failed to write highlight stylesheet: " + err.Error())
+	}
 
 	// Output RSS feed of posts
 	rssFile := filepath.Join(absOutputDir, "rss.xml")
 	postRSSFeed(publishedPosts, rssFile)
+
+	// Output Atom feed of posts
+	atomFile := filepath.Join(absOutputDir, "atom.xml")
+	postAtomFeed(publishedPosts, atomFile)
+
+	// Output JSON Feed of posts
+	jsonFeedFile := filepath.Join(absOutputDir, "feed.json")
+	postJSONFeed(publishedPosts, jsonFeedFile)
+
+	// Output sitemap of all pages and robots.txt pointing at it
+	sitemapFile := filepath.Join(absOutputDir, "sitemap.xml")
+	postSitemap(publishedPosts, sitemapFile)
+	robotsFile := filepath.Join(absOutputDir, "robots.txt")
+	postRobotsTxt(robotsFile)
+
+	// Save the manifest so unchanged posts can be skipped next time
+	err = currentManifest.save(absOutputDir)
+	if err != nil {
+		log.Errorf("Failed to save build manifest: " + err.Error())
+	}
+
+	return nil
 }
 
 // buildPosts gets posts from a channel and builds them.
@@ -200,6 +317,51 @@ func buildPosts(outputDir string, posts <-chan *Post, wg *sync.WaitGroup) {
 	}
 }
 
+// renderPosts generates the HTML page for each post in parallel.
+// The posts should be sorted by date published with the newest first.
+func renderPosts(posts Posts, numWorkers int) {
+	if numWorkers > len(posts) {
+		numWorkers = len(posts)
+	}
+
+	var wg sync.WaitGroup
+	postJobs := make(chan int, numWorkers)
+
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range postJobs {
+				prev, next := adjacentPosts(posts, i)
+				err := posts[i].render(prev, next)
+				if err != nil {
+					log.Errorf("Error rendering post in '%v': "+err.Error(), posts[i].dir)
+				}
+			}
+		}()
+	}
+
+	for i := range posts {
+		postJobs <- i
+	}
+	close(postJobs)
+	wg.Wait()
+}
+
+// adjacentPosts returns the posts published before and after the post at index i.
+// The posts should be sorted by date published with the newest first.
+// Returns nil for a post if there isn't one.
+func adjacentPosts(posts Posts, i int) (prev, next *Post) {
+	if i+1 < len(posts) {
+		prev = posts[i+1]
+	}
+	if i > 0 {
+		next = posts[i-1]
+	}
+
+	return prev, next
+}
+
 // findPosts recursively searches a directory for posts.
 func findPosts(baseDir string) Posts {
 	log.Infof("Looking for posts recursivly in '%v'", baseDir)
@@ -234,10 +396,25 @@ func findPosts(baseDir string) Posts {
 			if file.IsDir() {
 				newDir := filepath.Join(nextDir, file.Name())
 				toProcess.PushBack(newDir)
+			} else if filepath.Ext(file.Name()) == ".md" {
+				// Markdown files with front matter outside of a post directory are single file posts
+				postFile := filepath.Join(nextDir, file.Name())
+				post, err := newFilePost(postFile)
+				if err == nil {
+					posts = append(posts, post)
+					log.Debugf("Found post in '%v'", postFile)
+				}
 			}
 		}
 	}
 
+	for _, post := range posts {
+		relPath, err := filepath.Rel(baseDir, post.dir)
+		if err == nil {
+			post.sourcePath = filepath.ToSlash(relPath)
+		}
+	}
+
 	log.Infof("Found %v posts in '%v'", len(posts), baseDir)
 	return posts
 }
@@ -270,7 +447,17 @@ func newPost(dir string) (*Post, error) {
 		return nil, fmt.Errorf("Dir '%v' is not a post directory", dir)
 	}
 
-	if !(metadata && content != "") {
+	// If there's no metadata file the metadata can be given as front matter in the content file
+	frontMatter := false
+	if !metadata && content != "" {
+		mdContent, err := ioutil.ReadFile(content)
+		if err != nil {
+			return nil, err
+		}
+		frontMatter = hasFrontMatter(mdContent)
+	}
+
+	if !((metadata || frontMatter) && content != "") {
 		log.Errorf("Dir '%v' is missing metadata or content file", dir)
 		return nil, fmt.Errorf("Dir '%v' is not a post directory", dir)
 	}
@@ -279,15 +466,36 @@ func newPost(dir string) (*Post, error) {
 		dir:         dir,
 		contentFile: content,
 		resourceDir: resourceDir,
+		frontMatter: frontMatter,
+	}, nil
+}
+
+// newFilePost checks if the given markdown file is a single file post and creates a post if so.
+// A single file post has its metadata in front matter at the top of the file.
+// Returns an error if the file is not a post.
+func newFilePost(file string) (*Post, error) {
+	mdContent, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if !hasFrontMatter(mdContent) {
+		return nil, fmt.Errorf("File '%v' is not a post", file)
+	}
+
+	return &Post{
+		dir:         file,
+		contentFile: file,
+		frontMatter: true,
 	}, nil
 }
 
 // build builds a post.
 // Content is parsed from the input directory of the post.
-// An output directory is created for the post and the built content is saved there.
+// An output directory is created for the post and its resources are copied there.
+// The HTML page of the post is generated later by render once all posts are sorted.
 func (p *Post) build(outputDir string) error {
-	var err error
-	p.metadata, err = parseMetadata(p.dir)
+	mdContent, err := p.readContent()
 	if err != nil {
 		return err
 	}
@@ -298,46 +506,60 @@ func (p *Post) build(outputDir string) error {
 		return nil
 	}
 
-	// Parse markdown content and save
-	mdContent, err := ioutil.ReadFile(p.contentFile)
+	// Only publish drafts if the drafts config option has been set
+	if !config.Values.Drafts && p.metadata.draft {
+		return nil
+	}
+
+	p.inputHash, err = pathHash(p.dir)
 	if err != nil {
 		return err
 	}
-	p.content = parsePostMarkdown(mdContent, renderPost)
-	p.preview = parsePostMarkdown(mdContent, renderPreview)
-	p.title = parsePostMarkdown(mdContent, renderTitle)
 
-	// If no link name has been given make one from the title
-	p.linkName = p.metadata.linkName
-	if p.linkName == "" {
-		linkRunes := []rune(linkNameDangerous.ReplaceAllString(p.title, ""))
-		maxLength := linkNameMaxLength
-		if len(linkRunes) < maxLength {
-			maxLength = len(linkRunes)
+	// If the post hasn't changed since the last build use the content from the manifest
+	// instead of parsing the markdown again
+	cached := lastManifest.lookup(p.dir, p.inputHash)
+	if cached != nil {
+		p.title = cached.Title
+		p.content = cached.Content
+		p.preview = cached.Preview
+		p.toc = cached.TOC
+	} else {
+		mdConfig, err := p.markdownConfig()
+		if err != nil {
+			return fmt.Errorf("Invalid markdown metadata: " + err.Error())
+		}
+
+		p.doc = parseMarkdown(mdContent, mdConfig)
+		p.content = renderMarkdown(p.doc, renderPost, mdConfig)
+		p.preview = p.previewHTML(mdConfig)
+		p.title = renderMarkdown(p.doc, renderTitle, mdConfig)
+		if p.tocEnabled() {
+			p.toc = tableOfContents(p.doc)
 		}
-		p.linkName = string(linkRunes[:maxLength])
 	}
 
-	// Remove potentially dangerous characters from link name, convert spaces
-	// to dashes and lowercase
-	p.linkName = linkNameDangerous.ReplaceAllString(p.linkName, "")
-	p.linkName = strings.ToLower(strings.ReplaceAll(p.linkName, " ", "-"))
-
-	// Build filepath for post from publish date and linkname
-	year := p.metadata.publishDate.Format("2006")
-	month := p.metadata.publishDate.Format("01")
-	p.urlPath = strings.Join([]string{config.Values.BaseUrlPath, year, month, p.linkName}, "/")
-	p.outputDir = filepath.Join(outputDir, year, month, p.linkName)
+	p.setOutputPath(outputDir)
 
 	// Do a uniqueness check on directory name
 	uniqueDirsLock.Lock()
 	duplicate, exists := uniqueDirs[p.outputDir]
 	if exists {
+		uniqueDirsLock.Unlock()
 		return fmt.Errorf("Same output directory as '%v'", duplicate.dir)
 	}
 	uniqueDirs[p.outputDir] = p
 	uniqueDirsLock.Unlock()
 
+	// Skip writing the output if it's already up to date
+	indexFile := filepath.Join(p.outputDir, "index.html")
+	if cached != nil && cached.OutputDir == p.outputDir && fileExists(indexFile) {
+		log.Debugf("Post in '%v' is unchanged, skipping", p.dir)
+		p.lastBuild = cached
+		p.published = true
+		return nil
+	}
+
 	// Make output directory
 	err = os.MkdirAll(p.outputDir, 0775)
 	if err != nil {
@@ -353,28 +575,127 @@ func (p *Post) build(outputDir string) error {
 		}
 	}
 
-	// Generate HTML of post from markdown and templates
-	indexFile := filepath.Join(p.outputDir, "index.html")
-	err = postToHTML(p, indexFile)
+	p.published = true
+	return nil
+}
+
+// render generates the HTML page of a post from its template.
+// The page links to the previous and next posts which can be nil if there isn't one.
+// The page isn't rendered again if neither the post nor its neighbours have changed since
+// the last build.
+func (p *Post) render(prev, next *Post) error {
+	p.neighboursHash = neighboursHash(prev, next)
+
+	if p.lastBuild != nil && p.lastBuild.NeighboursHash == p.neighboursHash {
+		currentManifest.record(p)
+		return nil
+	}
+
+	err := postToHTML(p, prev, next, filepath.Join(p.outputDir, "index.html"))
 	if err != nil {
 		return err
 	}
 
-	p.published = true
+	currentManifest.record(p)
 	return nil
 }
 
-// parsePostMarkdown converts the markdown content of the post to a HTML string.
+// readContent reads the markdown content and metadata of a post.
+// The metadata is stored in the post and the markdown content is returned with any
+// front matter removed.
+func (p *Post) readContent() ([]byte, error) {
+	mdContent, err := ioutil.ReadFile(p.contentFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.frontMatter {
+		p.metadata, mdContent, err = parseFrontMatter(mdContent)
+	} else {
+		p.metadata, err = parseMetadata(p.dir)
+		mdContent = stripFrontMatter(mdContent)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return mdContent, nil
+}
+
+// setOutputPath sets the link name, URL path and output directory of a post.
+// The metadata and title of the post must already be set.
+func (p *Post) setOutputPath(outputDir string) {
+	p.linkName = makeLinkName(p.metadata.linkName, p.title)
+
+	// Build filepath for post from publish date and linkname
+	year := p.metadata.publishDate.Format("2006")
+	month := p.metadata.publishDate.Format("01")
+	p.urlPath = strings.Join([]string{config.Values.BaseUrlPath, year, month, p.linkName}, "/")
+	p.outputDir = filepath.Join(outputDir, year, month, p.linkName)
+}
+
+// makeLinkName creates the name used as the last part of the URL path of a post.
+// If no link name is given one is made from the title of the post.
+// Potentially dangerous characters are removed, spaces are converted to dashes and
+// the name is lowercased.
+func makeLinkName(linkName, title string) string {
+	if linkName == "" {
+		linkRunes := []rune(linkNameDangerous.ReplaceAllString(title, ""))
+		maxLength := linkNameMaxLength
+		if len(linkRunes) < maxLength {
+			maxLength = len(linkRunes)
+		}
+		linkName = string(linkRunes[:maxLength])
+	}
+
+	linkName = linkNameDangerous.ReplaceAllString(linkName, "")
+	return strings.ToLower(strings.ReplaceAll(linkName, " ", "-"))
+}
+
+// parseMarkdown parses the markdown content of a post into a syntax tree.
+// The tree can be rendered multiple times with renderMarkdown so the markdown only
+// needs parsing once. Headings after the title are given ids so they can be linked to.
+// The markdown config controls which markdown extensions are used.
+func parseMarkdown(mdContent []byte, mdConfig config.MarkdownConfig) ast.Node {
+	doc := markdown.Parse(mdContent, markdownParser(mdConfig))
+	addHeadingIDs(doc)
+
+	return doc
+}
+
+// previewHTML returns the HTML of the preview of the post.
+// The preview is the summary from the metadata if there is one, otherwise everything before
+// the <!--more--> marker or, if there isn't a marker, the first paragraph of the post.
+func (p *Post) previewHTML(mdConfig config.MarkdownConfig) string {
+	if p.metadata.summary != "" {
+		summary := []byte(p.metadata.summary)
+		return string(markdown.ToHTML(summary, markdownParser(mdConfig), htmlRenderer(mdConfig)))
+	}
+
+	return renderMarkdown(p.doc, renderPreview, mdConfig)
+}
+
+// tocEnabled returns true if a table of contents should be generated for the post.
+// The toc metadata key overrides the toc config option.
+func (p *Post) tocEnabled() bool {
+	if p.metadata.toc != nil {
+		return *p.metadata.toc
+	}
+
+	return config.Values.Toc
+}
+
+// renderMarkdown converts a parsed markdown document to a HTML string.
 // The mode argument controls whether the full post, a preview or just the title
-// is generated.
-func parsePostMarkdown(mdContent []byte, mode renderMode) string {
-	opts := html.RendererOptions{Flags: html.CommonFlags}
+// is generated and the markdown config sets the flags of the HTML renderer.
+func renderMarkdown(doc ast.Node, mode renderMode, mdConfig config.MarkdownConfig) string {
 	renderer := &postRenderer{
-		htmlRenderer: html.NewRenderer(opts),
+		htmlRenderer: htmlRenderer(mdConfig),
 		mode:         mode,
+		moreMarker:   findMoreMarker(doc),
 	}
 
-	return string(markdown.ToHTML(mdContent, nil, renderer))
+	return string(markdown.Render(doc, renderer))
 }
 
 // removeExtraOutputDirs removes directories from the output that don't have a post
@@ -388,10 +709,27 @@ func removeExtraOutputDirs(outputDir string) error {
 	uniqueDirsLock.Lock()
 	defer uniqueDirsLock.Unlock()
 
+	// Pages can be output anywhere so the pages from the last build are checked instead
+	removeExtraPageDirs(outputDir)
+
 	// Recursively search output directory for directories that look like a post
 	// directory but don't have a corresponding post in the input.
 	// Assumes any directory with a YYYY/MM/ prefix is a post directory.
 	for _, outputFile := range outputFileList {
+		if outputFile.IsDir() && outputFile.Name() == tagsDirName {
+			err = removeExtraDirs(filepath.Join(outputDir, outputFile.Name()), tagDirs)
+			if err != nil {
+				return err
+			}
+			removeExtraTagFeeds()
+		}
+		if outputFile.IsDir() && outputFile.Name() == listPageDirName {
+			err = removeExtraDirs(filepath.Join(outputDir, outputFile.Name()), listPageDirs)
+			if err != nil {
+				return err
+			}
+		}
+
 		if outputFile.IsDir() && looksLikeYear.MatchString(outputFile.Name()) {
 			yearDir := filepath.Join(outputDir, outputFile.Name())
 			yearFileList, err := ioutil.ReadDir(yearDir)
@@ -415,6 +753,67 @@ func removeExtraOutputDirs(outputDir string) error {
 							}
 						}
 					}
+
+					if !archiveDirs[monthDir] {
+						removeArchiveDir(monthDir)
+					}
+				}
+			}
+
+			if !archiveDirs[yearDir] {
+				removeArchiveDir(yearDir)
+			}
+		}
+	}
+
+	return nil
+}
+
+// removeArchiveDir removes the archive page of a year or month which no longer has any posts.
+// The directory is only removed if it's then empty so other files in the output are kept.
+func removeArchiveDir(dir string) {
+	os.Remove(filepath.Join(dir, "index.html"))
+	os.Remove(dir)
+}
+
+// removeExtraDirs removes the sub-directories of dir which aren't in the set of directories
+// to keep.
+func removeExtraDirs(dir string, keep DirSet) error {
+	fileList, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range fileList {
+		subDir := filepath.Join(dir, file.Name())
+		if file.IsDir() && !keep[subDir] {
+			os.RemoveAll(subDir)
+		}
+	}
+
+	return nil
+}
+
+// fileExists returns true if a file exists.
+func fileExists(file string) bool {
+	_, err := os.Stat(file)
+	return err == nil
+}
+
+// findMoreMarker returns the <!--more--> comment which marks the end of the preview of a post.
+// The marker can be on its own line or at the end of a paragraph at the top level of the post.
+// Returns nil if the post doesn't have a marker.
+func findMoreMarker(doc ast.Node) ast.Node {
+	for _, block := range doc.GetChildren() {
+		switch b := block.(type) {
+		case *ast.HTMLBlock:
+			if moreMarker.Match(bytes.TrimSpace(b.Literal)) {
+				return b
+			}
+		case *ast.Paragraph:
+			for _, inline := range b.GetChildren() {
+				if span, isSpan := inline.(*ast.HTMLSpan); isSpan && moreMarker.Match(bytes.TrimSpace(span.Literal)) {
+					return span
 				}
 			}
 		}
@@ -427,9 +826,20 @@ func removeExtraOutputDirs(outputDir string) error {
 // Generates the full content, a preview or just the title of the post depending
 // on the mode set in the postRenderer.
 func (r *postRenderer) RenderNode(w io.Writer, node ast.Node, entering bool) ast.WalkStatus {
-	if r.mode == renderPost {
+	// The more marker isn't output, in a preview it ends the preview
+	if node == r.moreMarker {
+		if r.mode == renderPreview {
+			if _, isBlock := node.(*ast.HTMLBlock); isBlock {
+				return ast.Terminate
+			}
+			r.previewEnd = node.GetParent()
+		}
+		return ast.GoToNext
+	}
+
+	if r.mode == renderPost || (r.mode == renderPreview && r.moreMarker != nil) {
 		r.rendering = true
-		// Render whole post except title (the first heading)
+		// Render whole post, or everything before the more marker, except title (the first heading)
 		if !r.seenTitle {
 			switch node.(type) {
 			case *ast.Heading:
@@ -441,6 +851,12 @@ func (r *postRenderer) RenderNode(w io.Writer, node ast.Node, entering bool) ast
 				}
 			}
 		}
+
+		// Leaving the paragraph containing the more marker so render end and terminate
+		if node == r.previewEnd && !entering {
+			r.htmlRenderer.RenderNode(w, node, entering)
+			return ast.Terminate
+		}
 	} else if r.mode == renderPreview {
 		// Render a preview of the post (the first paragraph)
 		switch node.(type) {
@@ -474,6 +890,17 @@ func (r *postRenderer) RenderNode(w io.Writer, node ast.Node, entering bool) ast
 	}
 
 	if r.rendering {
+		// Add a link to the heading before closing it, previews are shown on other pages so
+		// don't get links
+		heading, isHeading := node.(*ast.Heading)
+		if isHeading && !entering && heading.HeadingID != "" && config.Values.HeadingAnchors && r.mode == renderPost {
+			io.WriteString(w, `<a class="heading-anchor" href="#`+heading.HeadingID+`">#</a>`)
+		}
+
+		// Code blocks with a known language are syntax highlighted
+		if codeBlock, isCode := node.(*ast.CodeBlock); isCode && highlightCode(w, codeBlock) {
+			return ast.GoToNext
+		}
 		return r.htmlRenderer.RenderNode(w, node, entering)
 	} else {
 		return ast.GoToNext
@@ -1,6 +1,7 @@
 package posts
 
 import (
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
@@ -19,7 +20,7 @@ func TestFindPosts(t *testing.T) {
 	log.SetLevel(log.FatalLevel)
 
 	posts := findPosts(inputDir)
-	assert.Equal(t, 3, len(posts), "Incorrect number of posts found")
+	assert.Equal(t, 6, len(posts), "Incorrect number of posts found")
 }
 
 func TestBuildPosts(t *testing.T) {
@@ -34,17 +35,26 @@ func TestBuildPosts(t *testing.T) {
 	fakePostDir := filepath.Join(tmpDir, "2020/04/old-post")
 	os.MkdirAll(fakePostDir, 0775)
 
+	// Add extra tag directory to output which should be removed automatically
+	fakeTagDir := filepath.Join(tmpDir, "tags/old-tag")
+	os.MkdirAll(fakeTagDir, 0775)
+
 	BuildPosts(inputDir, tmpDir)
 
 	// Check extra post directory has been removed
 	if _, err := os.Stat(fakePostDir); !os.IsNotExist(err) {
 		t.Errorf("Old post directory hasn't been removed")
 	}
+	if _, err := os.Stat(fakeTagDir); !os.IsNotExist(err) {
+		t.Errorf("Old tag directory hasn't been removed")
+	}
 
 	expectedDirs := []string{
 		"2021/01/2021-01-post-1/",
 		"2021/01/post2-2021-01/",
 		"2020/12/post-2-202012/",
+		"2021/03/2021-03-front-matter/",
+		"2021/04/single-post/",
 	}
 
 	for _, dir := range expectedDirs {
@@ -59,7 +69,16 @@ func TestBuildPosts(t *testing.T) {
 		}
 	}
 
-	expectedRootFiles := []string{"index.html", "test.css", "rss.xml"}
+	expectedRootFiles := []string{
+		"index.html",
+		"test.css",
+		"rss.xml",
+		"atom.xml",
+		"feed.json",
+		"tags/index.html",
+		"tags/happy/index.html",
+		"tags/toml/index.html",
+	}
 	for _, file := range expectedRootFiles {
 		mainIndex := filepath.Join(tmpDir, file)
 		if _, err := os.Stat(mainIndex); os.IsNotExist(err) {
@@ -67,6 +86,12 @@ func TestBuildPosts(t *testing.T) {
 		}
 	}
 
+	// Drafts shouldn't be published by default
+	draftDir := filepath.Join(tmpDir, "2021/02/2021-02-draft")
+	if _, err := os.Stat(draftDir); !os.IsNotExist(err) {
+		t.Errorf("Draft post directory '%v' exists", draftDir)
+	}
+
 	expectedResourceFiles := []string{"2020/12/post-2-202012/static.file"}
 	for _, file := range expectedResourceFiles {
 		resourceFile := filepath.Join(tmpDir, file)
@@ -75,3 +100,187 @@ func TestBuildPosts(t *testing.T) {
 		}
 	}
 }
+
+func TestBuildDrafts(t *testing.T) {
+	config.Init([]string{"-drafts"})
+	log.SetLevel(log.FatalLevel)
+	config.Values.StaticDir = staticDir
+	config.Values.TemplateDir = templateDir
+
+	tmpDir := t.TempDir()
+	BuildPosts(inputDir, tmpDir)
+
+	draftIndex := filepath.Join(tmpDir, "2021/02/2021-02-draft/index.html")
+	if _, err := os.Stat(draftIndex); os.IsNotExist(err) {
+		t.Errorf("Expected draft html file '%v' doesn't exist", draftIndex)
+	}
+}
+
+var linkNameTests = []struct {
+	linkName string
+	title    string
+	expected string
+}{
+	{"", "My First Post", "my-first-post"},
+	{"Custom Link", "My First Post", "custom-link"},
+	{"", "What? A post: 50% done", "what-a-post-50-done"},
+	{"", "A very long title that goes on and on for more than fifty characters", "a-very-long-title-that-goes-on-and-on-for-more-tha"},
+}
+
+func TestMakeLinkName(t *testing.T) {
+	for _, tc := range linkNameTests {
+		assert.Equal(t, tc.expected, makeLinkName(tc.linkName, tc.title), "Incorrect link name for '%v'", tc.title)
+	}
+}
+
+func TestBuildPaginatedPosts(t *testing.T) {
+	config.Init([]string{"-postsPerPage", "2"})
+	log.SetLevel(log.FatalLevel)
+	config.Values.StaticDir = staticDir
+	config.Values.TemplateDir = templateDir
+
+	tmpDir := t.TempDir()
+
+	// Add extra page directory to output which should be removed automatically
+	fakePageDir := filepath.Join(tmpDir, "page/4")
+	os.MkdirAll(fakePageDir, 0775)
+
+	BuildPosts(inputDir, tmpDir)
+
+	if _, err := os.Stat(fakePageDir); !os.IsNotExist(err) {
+		t.Errorf("Old page directory hasn't been removed")
+	}
+
+	expectedFiles := []string{"index.html", "page/2/index.html", "page/3/index.html"}
+	for _, file := range expectedFiles {
+		pageFile := filepath.Join(tmpDir, file)
+		if _, err := os.Stat(pageFile); os.IsNotExist(err) {
+			t.Errorf("Expected html file '%v' doesn't exist", pageFile)
+		}
+	}
+}
+
+var paginationTests = []struct {
+	pageNum    int
+	totalPages int
+	expected   paginationData
+}{
+	{1, 1, paginationData{CurrentPage: 1, TotalPages: 1}},
+	{1, 3, paginationData{CurrentPage: 1, TotalPages: 3, NextUrl: "/blog/page/2"}},
+	{2, 3, paginationData{CurrentPage: 2, TotalPages: 3, PrevUrl: "/blog/", NextUrl: "/blog/page/3"}},
+	{3, 3, paginationData{CurrentPage: 3, TotalPages: 3, PrevUrl: "/blog/page/2"}},
+}
+
+func TestPagination(t *testing.T) {
+	config.Init([]string{})
+	config.Values.BaseUrlPath = "/blog"
+
+	for _, tc := range paginationTests {
+		assert.Equal(t, tc.expected, pagination(tc.pageNum, tc.totalPages), "Incorrect pagination for page %v", tc.pageNum)
+	}
+
+	config.Values.PostsPerPage = 2
+	assert.Equal(t, 2, pagination(1, 3).PostsPerPage, "Incorrect posts per page")
+
+	posts := make(Posts, 5)
+	assert.Equal(t, 3, len(paginatePosts(posts, 2)), "Incorrect number of pages")
+	assert.Equal(t, 1, len(paginatePosts(posts, 0)), "Incorrect number of pages with pagination disabled")
+	assert.Equal(t, 1, len(paginatePosts(Posts{}, 2)), "Incorrect number of pages with no posts")
+}
+
+func TestPostNeighbours(t *testing.T) {
+	config.Init([]string{})
+	log.SetLevel(log.FatalLevel)
+	config.Values.StaticDir = staticDir
+	config.Values.TemplateDir = templateDir
+
+	tmpDir := t.TempDir()
+	err := BuildPosts(inputDir, tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to build posts: %v", err.Error())
+	}
+
+	assert := assert.New(t)
+
+	expectedLinks := map[string][]string{
+		// Oldest post only has a next post
+		"2020/12/post-2-202012/index.html": {`<a id="next-post" href="/2021/01/post2-2021-01">`},
+		"2021/01/2021-01-post-1/index.html": {
+			`<a id="prev-post" href="/2021/01/post2-2021-01">`,
+			`<a id="next-post" href="/2021/03/2021-03-front-matter">`,
+		},
+		// Newest post only has a previous post
+		"2021/04/single-post/index.html": {`<a id="prev-post" href="/2021/03/2021-03-front-matter">`},
+	}
+	for file, links := range expectedLinks {
+		html, err := ioutil.ReadFile(filepath.Join(tmpDir, file))
+		if err != nil {
+			t.Errorf("Expected html file '%v' doesn't exist", file)
+			continue
+		}
+
+		for _, link := range links {
+			assert.Contains(string(html), link, "Missing link in '%v'", file)
+		}
+	}
+
+	html, _ := ioutil.ReadFile(filepath.Join(tmpDir, "2020/12/post-2-202012/index.html"))
+	assert.NotContains(string(html), `id="prev-post"`, "Oldest post has a previous post")
+	html, _ = ioutil.ReadFile(filepath.Join(tmpDir, "2021/04/single-post/index.html"))
+	assert.NotContains(string(html), `id="next-post"`, "Newest post has a next post")
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	mdContent := []byte("# Post Title\n\nFirst paragraph.\n\n## Section\n\nSecond paragraph.\n")
+	doc := parseMarkdown(mdContent, config.Values.Markdown)
+
+	assert := assert.New(t)
+
+	// The same document can be rendered in every mode
+	assert.Equal("Post Title", renderMarkdown(doc, renderTitle, config.Values.Markdown), "Incorrect title")
+	assert.Equal("<p>First paragraph.</p>\n", renderMarkdown(doc, renderPreview, config.Values.Markdown), "Incorrect preview")
+
+	content := renderMarkdown(doc, renderPost, config.Values.Markdown)
+	assert.NotContains(content, "Post Title", "Title included in content")
+	assert.Contains(content, `<h2 id="section">Section</h2>`, "Section heading missing from content")
+	assert.Contains(content, "<p>Second paragraph.</p>", "Second paragraph missing from content")
+
+	// Rendering again gives the same output
+	assert.Equal(content, renderMarkdown(doc, renderPost, config.Values.Markdown), "Rendering a document twice gave different output")
+}
+
+func TestPreview(t *testing.T) {
+	config.Init([]string{})
+	assert := assert.New(t)
+
+	previewTests := []struct {
+		markdown string
+		summary  string
+		preview  string
+	}{
+		// First paragraph is the default preview
+		{"# Title\n\n![cat](cat.jpg)\n\nSecond paragraph.\n", "", "<p><img src=\"cat.jpg\" alt=\"cat\" /></p>\n"},
+		// Everything before a marker on its own line
+		{"# Title\n\n![cat](cat.jpg)\n\n- One\n- Two\n\n<!--more-->\n\nRest of post.\n", "",
+			"<p><img src=\"cat.jpg\" alt=\"cat\" /></p>\n\n<ul>\n<li>One</li>\n<li>Two</li>\n</ul>\n"},
+		// Everything up to the end of a paragraph with a marker in it
+		{"# Title\n\nShort intro.\n\nLonger intro. <!-- more -->\n\nRest of post.\n", "",
+			"<p>Short intro.</p>\n\n<p>Longer intro. </p>\n"},
+		// Summary overrides the marker
+		{"# Title\n\nIntro.\n\n<!--more-->\n\nRest of post.\n", "A *custom* summary.",
+			"<p>A <em>custom</em> summary.</p>\n"},
+	}
+
+	for i, tc := range previewTests {
+		post := &Post{
+			metadata: &PostMetadata{summary: tc.summary},
+			doc:      parseMarkdown([]byte(tc.markdown), config.Values.Markdown),
+		}
+
+		assert.Equal(tc.preview, post.previewHTML(config.Values.Markdown), "Incorrect preview for test %v", i)
+
+		content := renderMarkdown(post.doc, renderPost, config.Values.Markdown)
+		assert.NotContains(content, "more", "More marker in content for test %v", i)
+		assert.NotContains(content, "Title", "Title in content for test %v", i)
+	}
+}
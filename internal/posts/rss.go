@@ -3,7 +3,9 @@ package posts
 import (
 	"bufio"
 	"encoding/xml"
+	"fmt"
 	"os"
+	"path/filepath"
 	"regexp"
 	"time"
 
@@ -12,8 +14,14 @@ import (
 	"github.com/cswilson90/tribo/internal/config"
 )
 
-// RSSDateFormat is the format used to output dates in the RSS feed
-const RSSDateFormat = "Mon, 02 Jan 2006 15:04:05 MST"
+const (
+	// RSSDateFormat is the format used to output dates in the RSS feed
+	RSSDateFormat = "Mon, 02 Jan 2006 15:04:05 MST"
+
+	// RSSContentNamespace is the namespace of the RSS content module used to add the
+	// full content of posts to the RSS feed.
+	RSSContentNamespace = "http://purl.org/rss/1.0/modules/content/"
+)
 
 var (
 	removeOpeningPTag = regexp.MustCompile(`^\s*<p>\s*`)
@@ -23,9 +31,12 @@ var (
 // RSSXML describes the top level format used to encode the data into XML for the RSS feed.
 // See the RSS specification for more information.
 type RSSXML struct {
-	XMLName xml.Name    `xml:"rss"`
-	Version string      `xml:"version,attr"`
-	Channel *ChannelXML `xml:"channel"`
+	XMLName xml.Name `xml:"rss"`
+	Version string   `xml:"version,attr"`
+	// ContentNamespace declares the content module namespace.
+	// It's only set if the full content of posts is included in the feed.
+	ContentNamespace string      `xml:"xmlns:content,attr,omitempty"`
+	Channel          *ChannelXML `xml:"channel"`
 }
 
 // ChannelXML describes the structure or the XML for the channel of the RSS feed.
@@ -51,7 +62,10 @@ type ItemXML struct {
 	Link        string   `xml:"link"`
 	Description string   `xml:"description"`
 	Guid        string   `xml:"guid"`
-	PubDate     string   `xml:pubDate`
+	PubDate     string   `xml:"pubDate"`
+	// ContentEncoded contains the full HTML content of the post.
+	// It's only set if the rssFullContent config option is set.
+	ContentEncoded string `xml:"content:encoded,omitempty"`
 }
 
 // postRSSFeed outputs the RSS feed for the blog.
@@ -63,32 +77,63 @@ func postRSSFeed(posts Posts, outputFile string) {
 		return
 	}
 
-	log.Infof("Writing RSS XML to '%v'", outputFile)
+	channelXML := &ChannelXML{
+		Title:       config.Values.BlogName,
+		Link:        absoluteUrl(config.Values.BaseUrlPath),
+		Description: config.Values.BlogDescription,
+	}
 
-	// Add newest 10 posts to RSS feed
-	maxPosts := 10
-	if len(posts) < maxPosts {
-		maxPosts = len(posts)
+	writeRSSFeed(posts, channelXML, outputFile)
+}
+
+// postTagRSSFeeds outputs an RSS feed for each tag containing the posts with that tag.
+// The RSS feed for a tag is saved in "rss.xml" in the tag's directory.
+// Tag feeds are only generated if the tagRss config option is set.
+func postTagRSSFeeds(tags []*postTag, tagsDir string) {
+	if config.Values.NoRss || !config.Values.TagRss {
+		return
 	}
 
+	for _, tag := range tags {
+		channelXML := &ChannelXML{
+			Title:       config.Values.BlogName + " - " + tag.name,
+			Link:        absoluteUrl(tagUrl(tag.name)),
+			Description: "Posts tagged \"" + tag.name + "\" on " + config.Values.BlogName,
+		}
+
+		tagDir := filepath.Join(tagsDir, tag.dirName)
+		writeRSSFeed(tag.posts, channelXML, filepath.Join(tagDir, "rss.xml"))
+		tagFeedDirs[tagDir] = true
+	}
+}
+
+// writeRSSFeed adds the newest posts to an RSS channel and writes the feed to a file.
+// The title, link and description of the channel should already be set.
+// The posts should be sorted by date published.
+func writeRSSFeed(posts Posts, channelXML *ChannelXML, outputFile string) {
+	log.Infof("Writing RSS XML to '%v'", outputFile)
+
+	// Add newest posts to RSS feed
+	maxPosts := feedMaxPosts(posts)
+
 	postsXML := make([]*ItemXML, maxPosts)
 	for i := 0; i < maxPosts; i++ {
 		post := posts[i]
 
-		postLink := config.Values.RssLinkUrl + post.urlPath
-
-		// Post description is the post preview paragraph with opening and closing
-		// paragraph tags removed
-		description := removeOpeningPTag.ReplaceAllLiteralString(post.preview, "")
-		description = removeClosingPTag.ReplaceAllLiteralString(description, "")
+		postLink := absoluteUrl(post.urlPath)
 
+		// Post description is the post preview with the paragraph tags removed if it's
+		// a single paragraph
 		postsXML[i] = &ItemXML{
 			Title:       post.title,
 			Link:        postLink,
-			Description: description,
+			Description: stripParagraph(post.preview),
 			Guid:        postLink,
 			PubDate:     post.metadata.publishDate.Format(RSSDateFormat),
 		}
+		if config.Values.RssFullContent {
+			postsXML[i].ContentEncoded = post.content
+		}
 	}
 
 	lastDate := time.Now()
@@ -96,25 +141,30 @@ func postRSSFeed(posts Posts, outputFile string) {
 		lastDate = posts[0].metadata.publishDate
 	}
 
-	channelXML := &ChannelXML{
-		Title:         config.Values.BlogName,
-		Link:          config.Values.RssLinkUrl + config.Values.BaseUrlPath,
-		Description:   config.Values.BlogDescription,
-		LastBuildDate: lastDate.Format(RSSDateFormat),
-		PubDate:       time.Now().Format(RSSDateFormat),
-		TTL:           1800,
-		Items:         postsXML,
-	}
+	channelXML.LastBuildDate = lastDate.Format(RSSDateFormat)
+	channelXML.PubDate = time.Now().Format(RSSDateFormat)
+	channelXML.TTL = config.Values.RssTtl
+	channelXML.Items = postsXML
 
 	rssXML := &RSSXML{
 		Version: "2.0",
 		Channel: channelXML,
 	}
+	if config.Values.RssFullContent {
+		rssXML.ContentNamespace = RSSContentNamespace
+	}
 
-	xmlFile, err := os.Create(outputFile)
+	err := writeXMLFile(outputFile, rssXML)
 	if err != nil {
-		log.Errorf("Failed to open RSS file '%v': "+err.Error(), outputFile)
-		return
+		log.Errorf("Failed to write RSS file: " + err.Error())
+	}
+}
+
+// writeXMLFile writes a value encoded as indented XML, with an XML header, to a file.
+func writeXMLFile(path string, v interface{}) error {
+	xmlFile, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("Failed to open file '%v': "+err.Error(), path)
 	}
 	defer xmlFile.Close()
 
@@ -125,8 +175,28 @@ func postRSSFeed(posts Posts, outputFile string) {
 	xmlEncoder.Indent("", "  ")
 
 	// Encode calls Flush on Writer so don't need to flush afterwards
-	err = xmlEncoder.Encode(rssXML)
+	err = xmlEncoder.Encode(v)
 	if err != nil {
-		log.Errorf("Failed to write RSS file: " + err.Error())
+		return fmt.Errorf("Failed to encode XML file '%v': "+err.Error(), path)
 	}
+
+	return nil
+}
+
+// feedMaxPosts returns the number of posts to include in a feed.
+// This is the feedItems config option or the number of posts if there are fewer posts.
+// If feedItems isn't positive all posts are included.
+func feedMaxPosts(posts Posts) int {
+	maxPosts := config.Values.FeedItems
+	if maxPosts <= 0 || len(posts) < maxPosts {
+		maxPosts = len(posts)
+	}
+
+	return maxPosts
+}
+
+// absoluteUrl converts a URL path on the blog into an absolute URL.
+// Feeds need absolute URLs as they are read outside of the blog.
+func absoluteUrl(urlPath string) string {
+	return config.Values.RssLinkUrl + urlPath
 }
@@ -3,6 +3,8 @@ package posts
 import (
 	"bufio"
 	"encoding/xml"
+	"html/template"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
@@ -21,6 +23,7 @@ const (
 )
 
 func TestRSS(t *testing.T) {
+	config.Init([]string{})
 	config.Values.BlogName = blogName
 	config.Values.BlogDescription = blogDescription
 	config.Values.BaseUrlPath = baseUrlPath
@@ -54,12 +57,23 @@ func TestRSS(t *testing.T) {
 			preview:   "<p> Description Paragraph</p> ",
 			published: true,
 		},
+		&Post{
+			urlPath: "/2020/12/test-post-4",
+			metadata: &PostMetadata{
+				publishDate: time.Date(2020, time.December, 1, 0, 0, 0, 0, time.UTC),
+			},
+			title:     "Test Post 4",
+			preview:   "<p>First</p>\n\n<p>Second</p>\n",
+			published: true,
+		},
 	}
 
 	expectedDescriptions := []string{
 		"Preview Paragraph",
 		"Description",
 		"Description Paragraph",
+		// Previews with more than one paragraph keep their paragraph tags
+		"<p>First</p>\n\n<p>Second</p>",
 	}
 
 	tmpDir := t.TempDir()
@@ -103,3 +117,153 @@ func TestRSS(t *testing.T) {
 		assert.Equal(posts[i].metadata.publishDate.Format(RSSDateFormat), item.PubDate, "Incorrect pubdate for post %v", i)
 	}
 }
+
+func TestTagRSS(t *testing.T) {
+	config.Init([]string{"-tagRss"})
+	config.Values.BlogName = blogName
+	config.Values.RssLinkUrl = rssLinkUrl
+
+	posts := Posts{
+		&Post{
+			urlPath: "/2021/03/test-post-1",
+			metadata: &PostMetadata{
+				publishDate: time.Date(2021, time.March, 17, 0, 0, 0, 0, time.UTC),
+				tags:        []string{"Release Notes", "testing"},
+			},
+			title:     "Test Post 1",
+			preview:   "<p>Preview Paragraph</p>",
+			published: true,
+		},
+		&Post{
+			urlPath: "/2021/02/test-post-2",
+			metadata: &PostMetadata{
+				publishDate: time.Date(2021, time.February, 24, 0, 0, 0, 0, time.UTC),
+				tags:        []string{"testing"},
+			},
+			title:     "Test Post 2",
+			preview:   "<p>Description</p>",
+			published: true,
+		},
+	}
+
+	tmpDir := t.TempDir()
+	tags := groupPostsByTag(posts)
+	for _, tag := range tags {
+		os.MkdirAll(filepath.Join(tmpDir, tag.dirName), 0775)
+	}
+	postTagRSSFeeds(tags, tmpDir)
+
+	expectedFeeds := []struct {
+		file  string
+		title string
+		link  string
+		items int
+	}{
+		{"release-notes/rss.xml", blogName + " - Release Notes", rssLinkUrl + "/tags/release-notes", 1},
+		{"testing/rss.xml", blogName + " - testing", rssLinkUrl + "/tags/testing", 2},
+	}
+
+	for _, expected := range expectedFeeds {
+		rssFileName := filepath.Join(tmpDir, expected.file)
+		rssFile, err := os.Open(rssFileName)
+		if err != nil {
+			t.Fatalf("Failed to read RSS file '%v': %v", rssFileName, err.Error())
+		}
+
+		rssXML := &RSSXML{}
+		err = xml.NewDecoder(bufio.NewReader(rssFile)).Decode(rssXML)
+		rssFile.Close()
+		if err != nil {
+			t.Fatalf("Failed to parse RSS file '%v': %v", rssFileName, err.Error())
+		}
+
+		assert.Equal(t, expected.title, rssXML.Channel.Title, "Incorrect RSS channel title")
+		assert.Equal(t, expected.link, rssXML.Channel.Link, "Incorrect RSS channel link")
+		assert.Equal(t, expected.items, len(rssXML.Channel.Items), "Incorrect number of RSS items")
+	}
+
+	// Feeds from an earlier build are removed when tag feeds are turned off
+	config.Values.TagRss = false
+	tagDirs = make(DirSet)
+	tagFeedDirs = make(DirSet)
+	for _, tag := range tags {
+		tagDirs[filepath.Join(tmpDir, tag.dirName)] = true
+	}
+	postTagRSSFeeds(tags, tmpDir)
+	removeExtraTagFeeds()
+	for _, expected := range expectedFeeds {
+		assert.NoFileExists(t, filepath.Join(tmpDir, expected.file), "Tag feed not removed")
+	}
+
+	// No tag directories are created when there are no tag pages or tag feeds
+	oldTmpl := tmpl
+	defer func() { tmpl = oldTmpl }()
+	tmpl = template.New("tags")
+	outputDir := t.TempDir()
+	err := tagOutput(posts, outputDir)
+	assert.Nil(t, err, "Failed to generate tag output")
+	assert.NoDirExists(t, filepath.Join(outputDir, tagsDirName), "Tag directories created without tag pages or feeds")
+	assert.Empty(t, tagDirs, "Tag directories recorded without tag pages or feeds")
+}
+
+// rssContentXML is used to decode the namespaced full content elements in the RSS feed.
+type rssContentXML struct {
+	Items []struct {
+		PubDate        string `xml:"pubDate"`
+		ContentEncoded string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	} `xml:"channel>item"`
+}
+
+func TestRSSFullContent(t *testing.T) {
+	config.Init([]string{"-rssFullContent", "-feedItems", "1", "-rssTtl", "60"})
+
+	posts := Posts{
+		&Post{
+			urlPath: "/2021/03/test-post-1",
+			metadata: &PostMetadata{
+				publishDate: time.Date(2021, time.March, 17, 0, 0, 0, 0, time.UTC),
+			},
+			title:     "Test Post 1",
+			content:   "<p>Preview Paragraph</p><p>More content</p>",
+			preview:   "<p>Preview Paragraph</p>",
+			published: true,
+		},
+		&Post{
+			urlPath: "/2021/02/test-post-2",
+			metadata: &PostMetadata{
+				publishDate: time.Date(2021, time.February, 24, 0, 0, 0, 0, time.UTC),
+			},
+			title:     "Test Post 2",
+			content:   "<p>Content</p>",
+			preview:   "<p>Content</p>",
+			published: true,
+		},
+	}
+
+	tmpDir := t.TempDir()
+	rssFileName := filepath.Join(tmpDir, "rss.xml")
+	postRSSFeed(posts, rssFileName)
+
+	rssData, err := ioutil.ReadFile(rssFileName)
+	if err != nil {
+		t.Fatalf("Failed to read RSS file '%v': %v", rssFileName, err.Error())
+	}
+
+	rssXML := &RSSXML{}
+	err = xml.Unmarshal(rssData, rssXML)
+	if err != nil {
+		t.Fatalf("Failed to parse RSS file '%v': %v", rssFileName, err.Error())
+	}
+
+	contentXML := &rssContentXML{}
+	err = xml.Unmarshal(rssData, contentXML)
+	if err != nil {
+		t.Fatalf("Failed to parse RSS file '%v': %v", rssFileName, err.Error())
+	}
+
+	assert := assert.New(t)
+	assert.Equal(60, rssXML.Channel.TTL, "Incorrect RSS channel TTL")
+	assert.Equal(1, len(contentXML.Items), "Incorrect number of RSS items")
+	assert.Equal(posts[0].metadata.publishDate.Format(RSSDateFormat), contentXML.Items[0].PubDate, "Incorrect pubDate element")
+	assert.Equal(posts[0].content, contentXML.Items[0].ContentEncoded, "Incorrect full content")
+}
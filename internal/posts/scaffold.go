@@ -0,0 +1,113 @@
+package posts
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+
+	"github.com/cswilson90/tribo/internal/config"
+)
+
+// newPostMetadata defines the structure of the metadata file created for a new post.
+type newPostMetadata struct {
+	PublishDate string   `yaml:"publishdate"`
+	Tags        []string `yaml:"tags,omitempty"`
+}
+
+/*
+	NewPost creates the directory for a new post in postsDir and returns its path.
+
+	The post is created in "YYYY/MM/<link name>" where the link name is made from the
+	title in the same way as when the post is built. The directory contains a content.md
+	file with the title as a heading, a metadata.yaml file with the publish date and tags
+	and an empty resources directory.
+
+	An error is returned if an existing post would have the same output directory.
+*/
+func NewPost(postsDir, title string, tags []string, publishDate time.Time) (string, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return "", fmt.Errorf("No title given for new post")
+	}
+
+	mdContent := []byte("# " + title + "\n")
+	mdConfig := config.Values.Markdown
+
+	post := &Post{
+		metadata: &PostMetadata{publishDate: publishDate},
+		title:    renderMarkdown(parseMarkdown(mdContent, mdConfig), renderTitle, mdConfig),
+	}
+	post.setOutputPath("")
+	if post.linkName == "" {
+		return "", fmt.Errorf("Could not make a link name from title '%v'", title)
+	}
+
+	postDir := filepath.Join(postsDir, post.outputDir)
+	if fileExists(postDir) {
+		return "", fmt.Errorf("Directory '%v' already exists", postDir)
+	}
+
+	duplicate := findOutputDir(postsDir, post.outputDir)
+	if duplicate != nil {
+		return "", fmt.Errorf("Post would have the same output directory as '%v'", duplicate.dir)
+	}
+
+	metadataYAML, err := yaml.Marshal(&newPostMetadata{
+		PublishDate: publishDate.Format(dateFormat),
+		Tags:        tags,
+	})
+	if err != nil {
+		return "", fmt.Errorf("Failed to create metadata for new post: " + err.Error())
+	}
+
+	err = os.MkdirAll(filepath.Join(postDir, "resources"), 0775)
+	if err != nil {
+		return "", err
+	}
+
+	err = ioutil.WriteFile(filepath.Join(postDir, "content.md"), append(mdContent, '\n'), 0664)
+	if err != nil {
+		return "", err
+	}
+
+	err = ioutil.WriteFile(filepath.Join(postDir, "metadata.yaml"), metadataYAML, 0664)
+	if err != nil {
+		return "", err
+	}
+
+	log.Infof("Created new post in '%v'", postDir)
+	return postDir, nil
+}
+
+// findOutputDir looks for a post in postsDir with the given output directory.
+// The output directory should be relative to the root of the output e.g. "2021/04/test-post".
+// Returns nil if no post has the output directory.
+func findOutputDir(postsDir, outputDir string) *Post {
+	for _, post := range findPosts(postsDir) {
+		mdContent, err := post.readContent()
+		if err != nil {
+			log.Warnf("Could not read post in '%v': "+err.Error(), post.dir)
+			continue
+		}
+
+		mdConfig, err := post.markdownConfig()
+		if err != nil {
+			log.Warnf("Invalid markdown metadata in '%v': "+err.Error(), post.dir)
+			continue
+		}
+
+		post.title = renderMarkdown(parseMarkdown(mdContent, mdConfig), renderTitle, mdConfig)
+		post.setOutputPath("")
+		if post.outputDir == outputDir {
+			return post
+		}
+	}
+
+	return nil
+}
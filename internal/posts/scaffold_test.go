@@ -0,0 +1,56 @@
+package posts
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/otiai10/copy"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cswilson90/tribo/internal/config"
+)
+
+func TestNewPost(t *testing.T) {
+	config.Init([]string{})
+
+	postsDir := filepath.Join(t.TempDir(), "posts")
+	err := copy.Copy(inputDir, postsDir)
+	if err != nil {
+		t.Fatalf("Failed to copy test posts: %v", err.Error())
+	}
+
+	assert := assert.New(t)
+	publishDate := time.Date(2021, time.March, 15, 0, 0, 0, 0, time.UTC)
+
+	postDir, err := NewPost(postsDir, "My New Post", []string{"new", "testing"}, publishDate)
+	if err != nil {
+		t.Fatalf("Failed to create new post: %v", err.Error())
+	}
+	assert.Equal(filepath.Join(postsDir, "2021", "03", "my-new-post"), postDir, "Incorrect new post directory")
+	assert.DirExists(filepath.Join(postDir, "resources"), "New post has no resources directory")
+
+	post, err := newPost(postDir)
+	if err != nil {
+		t.Fatalf("New post isn't a valid post: %v", err.Error())
+	}
+	mdContent, err := post.readContent()
+	if err != nil {
+		t.Fatalf("Failed to read new post: %v", err.Error())
+	}
+	doc := parseMarkdown(mdContent, config.Values.Markdown)
+	assert.Equal("My New Post", renderMarkdown(doc, renderTitle, config.Values.Markdown), "Incorrect title for new post")
+	assert.Equal(publishDate, post.metadata.publishDate, "Incorrect publish date for new post")
+	assert.Equal([]string{"new", "testing"}, post.metadata.tags, "Incorrect tags for new post")
+
+	// Same directory as the post just created
+	_, err = NewPost(postsDir, "My New Post", nil, publishDate)
+	assert.Error(err, "Expected error creating post in existing directory")
+
+	// Same output directory as an existing post in a different input directory
+	_, err = NewPost(postsDir, "2021 03 Front Matter", nil, publishDate)
+	assert.Error(err, "Expected error creating post with the same output directory as an existing post")
+
+	_, err = NewPost(postsDir, "  ", nil, publishDate)
+	assert.Error(err, "Expected error creating post with no title")
+}
@@ -0,0 +1,147 @@
+package posts
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/cswilson90/tribo/internal/config"
+)
+
+// SitemapNamespace is the namespace of the sitemap protocol.
+const SitemapNamespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// listPages keeps track of the pages listing posts generated in the current build so
+// they can be added to the sitemap.
+// This includes the pages of the post list and the tag pages.
+var listPages []listPage
+
+// listPage stores a generated page which lists posts.
+type listPage struct {
+	urlPath string
+	// lastmod is the date the most recently changed post on the page was changed.
+	lastmod time.Time
+}
+
+// SitemapXML describes the top level format used to encode the data into XML for the sitemap.
+// See the sitemap protocol at sitemaps.org for more information.
+type SitemapXML struct {
+	XMLName xml.Name         `xml:"urlset"`
+	Xmlns   string           `xml:"xmlns,attr"`
+	Urls    []*SitemapUrlXML `xml:"url"`
+}
+
+// SitemapUrlXML describes the structure of the XML for a single page in the sitemap.
+type SitemapUrlXML struct {
+	Loc     string `xml:"loc"`
+	Lastmod string `xml:"lastmod,omitempty"`
+}
+
+// addListPage adds a page listing posts to the sitemap.
+func addListPage(urlPath string, posts Posts) {
+	listPages = append(listPages, listPage{
+		urlPath: urlPath,
+		lastmod: postsLastUpdated(posts),
+	})
+}
+
+// postsLastUpdated returns the date the most recently changed post in a list was changed.
+// The zero time is returned if there aren't any posts.
+func postsLastUpdated(posts Posts) time.Time {
+	var lastUpdated time.Time
+	for _, post := range posts {
+		updated := post.metadata.lastUpdated()
+		if updated.After(lastUpdated) {
+			lastUpdated = updated
+		}
+	}
+
+	return lastUpdated
+}
+
+// postSitemap outputs the sitemap for the blog.
+// The sitemap is saved in "sitemap.xml" in the root directory of the blog and lists
+// the list pages generated in this build, the standalone pages and every published post.
+func postSitemap(posts Posts, outputFile string) {
+	if config.Values.NoSitemap {
+		log.Infof("Not generating sitemap as it's disabled in the config")
+		return
+	}
+
+	log.Infof("Writing sitemap to '%v'", outputFile)
+
+	urlsXML := make([]*SitemapUrlXML, 0, len(listPages)+len(sitePages)+len(posts))
+	for _, page := range listPages {
+		urlsXML = append(urlsXML, sitemapUrl(page.urlPath, page.lastmod))
+	}
+	// Standalone pages don't have a date so are added without a last modified date
+	for _, page := range sitePages {
+		urlsXML = append(urlsXML, sitemapUrl(page.urlPath, time.Time{}))
+	}
+	for _, post := range posts {
+		urlsXML = append(urlsXML, sitemapUrl(post.urlPath, post.metadata.lastUpdated()))
+	}
+
+	sitemapXML := &SitemapXML{
+		Xmlns: SitemapNamespace,
+		Urls:  urlsXML,
+	}
+
+	err := writeXMLFile(outputFile, sitemapXML)
+	if err != nil {
+		log.Errorf("Failed to write sitemap file: " + err.Error())
+	}
+}
+
+// sitemapUrl returns the sitemap entry for a URL path on the blog.
+// The last modified date is left out if it's the zero time.
+func sitemapUrl(urlPath string, lastmod time.Time) *SitemapUrlXML {
+	urlXML := &SitemapUrlXML{Loc: absoluteUrl(urlPath)}
+	if !lastmod.IsZero() {
+		urlXML.Lastmod = lastmod.Format(dateFormat)
+	}
+
+	return urlXML
+}
+
+// postRobotsTxt outputs a robots.txt file for the blog.
+// The file disallows the paths in the robotsDisallow config option and points at the sitemap.
+// It isn't generated if there is a robots.txt in the static directory or the theme's static
+// directory so a hand written file isn't overwritten.
+func postRobotsTxt(outputFile string) {
+	for _, staticDir := range staticDirs() {
+		if fileExists(filepath.Join(staticDir, "robots.txt")) {
+			log.Infof("Not generating robots.txt as one exists in '%v'", staticDir)
+			return
+		}
+	}
+
+	log.Infof("Writing robots.txt to '%v'", outputFile)
+	if config.Values.BaseUrlPath != "" {
+		log.Warnf("baseUrlPath is set so robots.txt must be served from '/robots.txt' for crawlers to find it")
+	}
+
+	var robots strings.Builder
+	robots.WriteString("User-agent: *\n")
+	if len(config.Values.RobotsDisallow) == 0 {
+		// An empty disallow rule allows everything
+		robots.WriteString("Disallow:\n")
+	}
+	// Disallowed paths are relative to the blog so need the base URL path adding
+	for _, path := range config.Values.RobotsDisallow {
+		robots.WriteString("Disallow: " + config.Values.BaseUrlPath + "/" + strings.TrimPrefix(path, "/") + "\n")
+	}
+
+	if !config.Values.NoSitemap {
+		robots.WriteString("\nSitemap: " + absoluteUrl(config.Values.BaseUrlPath+"/sitemap.xml") + "\n")
+	}
+
+	err := ioutil.WriteFile(outputFile, []byte(robots.String()), 0664)
+	if err != nil {
+		log.Errorf("Failed to write robots.txt file '%v': "+err.Error(), outputFile)
+	}
+}
@@ -0,0 +1,120 @@
+package posts
+
+import (
+	"bufio"
+	"encoding/xml"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cswilson90/tribo/internal/config"
+)
+
+func TestSitemap(t *testing.T) {
+	config.Init([]string{})
+	config.Values.BaseUrlPath = baseUrlPath
+	config.Values.RssLinkUrl = rssLinkUrl
+
+	posts := Posts{
+		&Post{
+			urlPath: baseUrlPath + "/2021/03/test-post-1",
+			metadata: &PostMetadata{
+				publishDate: time.Date(2021, time.March, 17, 0, 0, 0, 0, time.UTC),
+				updateDate:  time.Date(2021, time.April, 2, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		&Post{
+			urlPath: baseUrlPath + "/2021/02/test-post-2",
+			metadata: &PostMetadata{
+				publishDate: time.Date(2021, time.February, 24, 0, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	listPages = make([]listPage, 0)
+	addListPage(pageUrl(1), posts)
+	addListPage(tagUrl("testing"), posts[1:])
+
+	tmpDir := t.TempDir()
+	sitemapFileName := filepath.Join(tmpDir, "sitemap.xml")
+	postSitemap(posts, sitemapFileName)
+
+	sitemapFile, err := os.Open(sitemapFileName)
+	if err != nil {
+		t.Fatalf("Failed to read sitemap file '%v': %v", sitemapFileName, err.Error())
+	}
+
+	sitemapDecoder := xml.NewDecoder(bufio.NewReader(sitemapFile))
+	sitemapXML := &SitemapXML{}
+
+	err = sitemapDecoder.Decode(sitemapXML)
+	if err != nil {
+		t.Fatalf("Failed to parse sitemap file '%v': %v", sitemapFileName, err.Error())
+	}
+
+	expected := []*SitemapUrlXML{
+		{Loc: rssLinkUrl + baseUrlPath + "/", Lastmod: "2021-04-02"},
+		{Loc: rssLinkUrl + baseUrlPath + "/tags/testing", Lastmod: "2021-02-24"},
+		{Loc: rssLinkUrl + baseUrlPath + "/2021/03/test-post-1", Lastmod: "2021-04-02"},
+		{Loc: rssLinkUrl + baseUrlPath + "/2021/02/test-post-2", Lastmod: "2021-02-24"},
+	}
+
+	assert := assert.New(t)
+	assert.Equal(SitemapNamespace, sitemapXML.Xmlns, "Incorrect sitemap namespace")
+	assert.Equal(expected, sitemapXML.Urls, "Incorrect sitemap URLs")
+}
+
+func TestRobotsTxt(t *testing.T) {
+	config.Init([]string{})
+	config.Values.RssLinkUrl = rssLinkUrl
+	config.Values.StaticDir = t.TempDir()
+
+	tests := []struct {
+		baseUrlPath string
+		disallow    []string
+		noSitemap   bool
+		expected    string
+	}{
+		{
+			baseUrlPath: baseUrlPath,
+			expected:    "User-agent: *\nDisallow:\n\nSitemap: " + rssLinkUrl + baseUrlPath + "/sitemap.xml\n",
+		},
+		{
+			baseUrlPath: baseUrlPath,
+			disallow:    []string{"/private/", "search"},
+			expected: "User-agent: *\nDisallow: /blog/private/\nDisallow: /blog/search\n\nSitemap: " +
+				rssLinkUrl + baseUrlPath + "/sitemap.xml\n",
+		},
+		{
+			disallow: []string{"/private/", "/search"},
+			expected: "User-agent: *\nDisallow: /private/\nDisallow: /search\n\nSitemap: " + rssLinkUrl + "/sitemap.xml\n",
+		},
+		{
+			disallow:  []string{"/private/"},
+			noSitemap: true,
+			expected:  "User-agent: *\nDisallow: /private/\n",
+		},
+	}
+
+	assert := assert.New(t)
+	tmpDir := t.TempDir()
+	robotsFileName := filepath.Join(tmpDir, "robots.txt")
+
+	for i, tc := range tests {
+		config.Values.BaseUrlPath = tc.baseUrlPath
+		config.Values.RobotsDisallow = tc.disallow
+		config.Values.NoSitemap = tc.noSitemap
+
+		postRobotsTxt(robotsFileName)
+
+		robots, err := ioutil.ReadFile(robotsFileName)
+		if err != nil {
+			t.Fatalf("Failed to read robots.txt file '%v': %v", robotsFileName, err.Error())
+		}
+		assert.Equal(tc.expected, string(robots), "Incorrect robots.txt for test %v", i+1)
+	}
+}
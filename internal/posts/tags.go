@@ -0,0 +1,218 @@
+package posts
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/cswilson90/tribo/internal/config"
+)
+
+// tagsDirName is the name of the directory in the output which contains the tag pages.
+const tagsDirName = "tags"
+
+// tagDirs keeps track of the tag directories created in the current build so
+// directories for tags which no longer exist can be removed.
+var tagDirs = make(DirSet)
+
+// tagFeedDirs keeps track of the tag directories given an RSS feed in the current build so
+// feeds which are no longer generated, e.g. because tagRss has been turned off, can be removed.
+var tagFeedDirs = make(DirSet)
+
+// tagData contains the template data for a single tag.
+type tagData struct {
+	Name string
+	// Url is the URL of the page listing all the posts with the tag.
+	Url string
+	// Count is the number of posts with the tag.
+	Count int
+}
+
+// tagPageData contains all the template data for rendering the page for a single tag.
+type tagPageData struct {
+	Common commonData
+	Tag    tagData
+	// Posts is the list of posts with the tag sorted by publish date.
+	Posts []postData
+}
+
+// tagListPageData contains all the template data for rendering the page listing all tags.
+type tagListPageData struct {
+	Common commonData
+	// Tags is a list of all the tags ordered alphabetically.
+	Tags []tagData
+}
+
+// postTag stores a tag along with the posts that have it.
+type postTag struct {
+	name    string
+	dirName string
+	posts   Posts
+}
+
+// tagOutput generates the output for each tag in its own directory in the "tags" directory.
+// This is the tag pages and, if enabled, an RSS feed for each tag.
+// The posts should be sorted by date published.
+func tagOutput(posts Posts, outputDir string) error {
+	tagDirs = make(DirSet)
+	tagFeedDirs = make(DirSet)
+
+	tags := groupPostsByTag(posts)
+	tagsDir := filepath.Join(outputDir, tagsDirName)
+
+	// Tag directories are only needed if there are tag pages or tag feeds to put in them
+	tagFeeds := config.Values.TagRss && !config.Values.NoRss
+	if tagFeeds || tagTemplatesExist() {
+		for _, tag := range tags {
+			tagDir := filepath.Join(tagsDir, tag.dirName)
+			err := os.MkdirAll(tagDir, 0775)
+			if err != nil {
+				return err
+			}
+			tagDirs[tagDir] = true
+		}
+	}
+
+	postTagRSSFeeds(tags, tagsDir)
+
+	return tagPagesHTML(tags, tagsDir)
+}
+
+// removeExtraTagFeeds removes the RSS feeds of tags which weren't given a feed in the current build.
+func removeExtraTagFeeds() {
+	for tagDir := range tagDirs {
+		if !tagFeedDirs[tagDir] {
+			os.Remove(filepath.Join(tagDir, "rss.xml"))
+		}
+	}
+}
+
+// tagPagesHTML generates a page for each tag listing the posts with that tag and a page
+// listing all the tags.
+// Tag pages are saved in "tags/<tag>/index.html" and use the "tag.html.tmpl" template.
+// The list of tags is saved in "tags/index.html" and uses the "tag_list.html.tmpl" template.
+func tagPagesHTML(tags []*postTag, tagsDir string) error {
+	if !tagTemplatesExist() {
+		log.Warnf("Not generating tag pages as the tag templates don't exist")
+		return nil
+	}
+
+	listData := tagListPageData{
+		Common: comData(),
+		Tags:   make([]tagData, len(tags)),
+	}
+	listData.Common.PageTitle = "Tags - " + config.Values.BlogName
+
+	for i, tag := range tags {
+		tmplData := tagPageData{
+			Common: comData(),
+			Tag:    tag.data(),
+			Posts:  make([]postData, len(tag.posts)),
+		}
+		tmplData.Common.PageTitle = tag.name + " - " + config.Values.BlogName
+		for j, post := range tag.posts {
+			tmplData.Posts[j] = postToPostData(post, true)
+		}
+
+		tagFile := filepath.Join(tagsDir, tag.dirName, "index.html")
+		err := renderTemplate("tag.html.tmpl", tagFile, tmplData)
+		if err != nil {
+			return err
+		}
+		addListPage(tagUrl(tag.name), tag.posts)
+
+		listData.Tags[i] = tag.data()
+	}
+
+	err := os.MkdirAll(tagsDir, 0775)
+	if err != nil {
+		return err
+	}
+
+	err = renderTemplate("tag_list.html.tmpl", filepath.Join(tagsDir, "index.html"), listData)
+	if err != nil {
+		return err
+	}
+	addListPage(config.Values.BaseUrlPath+"/"+tagsDirName+"/", allTagPosts(tags))
+
+	return nil
+}
+
+// tagTemplatesExist returns whether the templates needed to generate the tag pages exist.
+func tagTemplatesExist() bool {
+	return tmpl.Lookup("tag.html.tmpl") != nil && tmpl.Lookup("tag_list.html.tmpl") != nil
+}
+
+// groupPostsByTag returns a list of all the tags of the posts ordered alphabetically.
+// Tags which would have the same output directory are treated as the same tag.
+func groupPostsByTag(posts Posts) []*postTag {
+	tagsByDir := make(map[string]*postTag)
+	tags := make([]*postTag, 0)
+
+	for _, post := range posts {
+		for _, name := range post.metadata.tags {
+			dirName := tagDirName(name)
+			if dirName == "" {
+				continue
+			}
+
+			tag, exists := tagsByDir[dirName]
+			if !exists {
+				tag = &postTag{
+					name:    name,
+					dirName: dirName,
+					posts:   make(Posts, 0),
+				}
+				tagsByDir[dirName] = tag
+				tags = append(tags, tag)
+			} else if tag.name != name {
+				log.Warnf("Tags '%v' and '%v' have the same URL, treating them as the same tag", tag.name, name)
+			}
+
+			tag.posts = append(tag.posts, post)
+		}
+	}
+
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].name < tags[j].name
+	})
+
+	return tags
+}
+
+// allTagPosts returns every post which has at least one tag.
+func allTagPosts(tags []*postTag) Posts {
+	seen := make(map[*Post]bool)
+	posts := make(Posts, 0)
+	for _, tag := range tags {
+		for _, post := range tag.posts {
+			if !seen[post] {
+				seen[post] = true
+				posts = append(posts, post)
+			}
+		}
+	}
+
+	return posts
+}
+
+// data returns the template data for a tag.
+func (t *postTag) data() tagData {
+	return tagData{
+		Name:  t.name,
+		Url:   tagUrl(t.name),
+		Count: len(t.posts),
+	}
+}
+
+// tagDirName returns the name of the output directory for a tag.
+func tagDirName(tag string) string {
+	return makeLinkName(tag, "")
+}
+
+// tagUrl returns the URL path of the page for a tag.
+func tagUrl(tag string) string {
+	return config.Values.BaseUrlPath + "/" + tagsDirName + "/" + tagDirName(tag)
+}
@@ -2,10 +2,12 @@ package posts
 
 import (
 	"bufio"
+	"embed"
 	"html/template"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -23,6 +25,11 @@ type commonData struct {
 	CurrentYear     string
 	// PageTitle is the HTML title of the page.
 	PageTitle string
+	// Pages links to all the standalone pages of the blog for use in navigation menus.
+	Pages []pageLinkData
+	// HighlightCssUrl is the URL of the stylesheet for highlighted code.
+	// It's empty unless the highlightClasses config option is set.
+	HighlightCssUrl string
 }
 
 // postData contains the template data for a single blog post.
@@ -31,9 +38,28 @@ type postData struct {
 	Content     template.HTML
 	Preview     template.HTML
 	PublishDate string
+	// PublishTime is the publish date of the post so templates can format it themselves.
+	PublishTime time.Time
+	// UpdateTime is the date the post was last updated.
+	// It's the zero time if no update date has been given.
+	UpdateTime time.Time
 	// Url is the URL used to link to the post.
 	Url  string
 	Tags []string
+	// TagLinks contains the name and URL of the page for each of the post's tags.
+	TagLinks []tagData
+	// Draft is true if the post is a draft. Drafts are only published if the drafts
+	// config option is set so templates can use this to show a banner on drafts.
+	Draft bool
+	// Params contains any keys in the post metadata which aren't used by Tribo.
+	Params map[string]interface{}
+	// SourcePath is the path of the post's directory, or the file for single file posts,
+	// relative to the posts directory using "/" as the separator.
+	SourcePath string
+	// TOC is the table of contents of the post and TOCHTML is the same rendered as nested lists.
+	// They are empty unless a table of contents is enabled for the post.
+	TOC     []tocEntry
+	TOCHTML template.HTML
 }
 
 // postListPageData contains all the template data for rendering the post list page.
@@ -42,31 +68,95 @@ type postListPageData struct {
 	Posts  []postData
 	// AllTags is a list of unique tags from all the posts that are in the post list.
 	AllTags []string
+	// Pagination contains details of the other pages of the post list.
+	// If the postsPerPage config option isn't set there is only a single page.
+	Pagination paginationData
+}
+
+// paginationData contains the template data for navigating between pages of the post list.
+type paginationData struct {
+	// CurrentPage is the number of the current page starting from 1.
+	CurrentPage int
+	TotalPages  int
+	// PrevUrl and NextUrl link to the previous and next pages.
+	// They are empty if there is no previous or next page.
+	PrevUrl string
+	NextUrl string
+	// PostsPerPage is the postsPerPage config option.
+	// It's 0 if the post list is on a single page.
+	PostsPerPage int
 }
 
 // postPageData contains all the template data for rendering a single blog post page.
 type postPageData struct {
 	Common commonData
 	Post   postData
+	// Prev and Next are the posts published before and after the post.
+	// They are nil if there isn't one.
+	Prev *postData
+	Next *postData
 }
 
-// tmpl stores the parsed templates used to render all post output.
-var tmpl *template.Template
+// listPageDirName is the name of the directory in the output which contains the pages of the
+// post list after the first.
+const listPageDirName = "page"
+
+// defaultTemplatesDir is the directory of the default templates in defaultTemplates.
+const defaultTemplatesDir = "defaults/templates"
+
+var (
+	// defaultTemplates contains the templates used if the template directory doesn't
+	// have a template with the same name.
+	//go:embed defaults/templates
+	defaultTemplates embed.FS
+
+	// templatePatterns are the patterns of files in the template directory that are parsed.
+	// Includes are parsed first so the main templates can use them.
+	templatePatterns = []string{filepath.Join("includes", "*.html.tmpl"), "*.html.tmpl"}
+	// defaultTemplatePatterns are the patterns of the default templates that are parsed.
+	defaultTemplatePatterns = []string{
+		defaultTemplatesDir + "/includes/*.html.tmpl",
+		defaultTemplatesDir + "/*.html.tmpl",
+	}
+
+	// tmpl stores the parsed templates used to render all post output.
+	tmpl *template.Template
+
+	// listPageDirs keeps track of the post list page directories created in the current build
+	// so directories for pages which no longer exist can be removed.
+	listPageDirs = make(DirSet)
+)
 
 // initTemplates initialises the Template variable for use when generating posts.
+// The functions from templateFuncs are available to all templates.
+// The default templates are parsed first then the templates in the theme, if one is set,
+// and finally the templates in the template directory. A template replaces any template
+// with the same name parsed before it.
 // This function needs to be called before generating post output files.
 func initTemplates() error {
-	includesPattern := filepath.Join(config.Values.TemplateDir, "includes", "*.html.tmpl")
 	var err error
-	tmpl, err = template.ParseGlob(includesPattern)
+	tmpl, err = template.New("").Funcs(templateFuncs()).ParseFS(defaultTemplates, defaultTemplatePatterns...)
 	if err != nil {
 		return err
 	}
 
-	templatePattern := filepath.Join(config.Values.TemplateDir, "*.html.tmpl")
-	tmpl, err = tmpl.ParseGlob(templatePattern)
-	if err != nil {
-		return err
+	for _, dir := range templateDirs() {
+		for _, pattern := range templatePatterns {
+			files, err := filepath.Glob(filepath.Join(dir, pattern))
+			if err != nil {
+				return err
+			}
+
+			// ParseFiles fails if there are no files so only parse if some exist
+			if len(files) == 0 {
+				continue
+			}
+
+			tmpl, err = tmpl.ParseFiles(files...)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
@@ -74,13 +164,23 @@ func initTemplates() error {
 
 // postToHTML generates a posts HTML content and writes it to an output file.
 // It uses the "post.html.tmpl" template.
-func postToHTML(post *Post, outputFilename string) error {
+// The previous and next posts are linked to from the page and can be nil if there isn't one.
+func postToHTML(post, prev, next *Post, outputFilename string) error {
 	postData := postToPostData(post, false)
 	tmplData := postPageData{
 		Common: comData(),
 		Post:   postData,
 	}
 
+	if prev != nil {
+		prevData := postToPostData(prev, true)
+		tmplData.Prev = &prevData
+	}
+	if next != nil {
+		nextData := postToPostData(next, true)
+		tmplData.Next = &nextData
+	}
+
 	tmplData.Common.PageTitle = post.title
 
 	return renderTemplate("post.html.tmpl", outputFilename, tmplData)
@@ -88,30 +188,105 @@ func postToHTML(post *Post, outputFilename string) error {
 
 // postListHTML generates the HTML for the list of posts used as the main page for the blog.
 // It uses the "post_list.html.tmpl" template file.
-func postListHTML(posts Posts, outputFilename string) error {
-	tmplData := postListPageData{
-		Common: comData(),
-		Posts:  make([]postData, len(posts)),
-	}
+// If the postsPerPage config option is set the list is split into pages. The first page is
+// saved in "index.html" in the output directory and the others in "page/<number>/index.html".
+func postListHTML(posts Posts, outputDir string) error {
+	listPageDirs = make(DirSet)
 
 	uniqueTags := make(map[string]struct{})
-	for i, post := range posts {
-		tmplData.Posts[i] = postToPostData(post, true)
-
-		for _, tag := range tmplData.Posts[i].Tags {
+	for _, post := range posts {
+		for _, tag := range post.metadata.tags {
 			uniqueTags[tag] = struct{}{}
 		}
 	}
 
-	tmplData.AllTags = make([]string, len(uniqueTags))
+	allTags := make([]string, len(uniqueTags))
 	i := 0
 	for tag := range uniqueTags {
-		tmplData.AllTags[i] = tag
+		allTags[i] = tag
 		i++
 	}
-	sort.Strings(tmplData.AllTags)
+	sort.Strings(allTags)
+
+	pages := paginatePosts(posts, config.Values.PostsPerPage)
+	for i, pagePosts := range pages {
+		pageNum := i + 1
+		tmplData := postListPageData{
+			Common:     comData(),
+			Posts:      make([]postData, len(pagePosts)),
+			AllTags:    allTags,
+			Pagination: pagination(pageNum, len(pages)),
+		}
+
+		for j, post := range pagePosts {
+			tmplData.Posts[j] = postToPostData(post, true)
+		}
+
+		pageDir := outputDir
+		if pageNum > 1 {
+			pageDir = filepath.Join(outputDir, listPageDirName, strconv.Itoa(pageNum))
+			err := os.MkdirAll(pageDir, 0775)
+			if err != nil {
+				return err
+			}
+			listPageDirs[pageDir] = true
+		}
 
-	return renderTemplate("post_list.html.tmpl", outputFilename, tmplData)
+		err := renderTemplate("post_list.html.tmpl", filepath.Join(pageDir, "index.html"), tmplData)
+		if err != nil {
+			return err
+		}
+		addListPage(pageUrl(pageNum), pagePosts)
+	}
+
+	return nil
+}
+
+// paginatePosts splits a list of posts into pages with at most perPage posts on each page.
+// If perPage isn't positive all posts are put on a single page.
+// There is always at least one page even if there are no posts.
+func paginatePosts(posts Posts, perPage int) []Posts {
+	if perPage <= 0 || len(posts) <= perPage {
+		return []Posts{posts}
+	}
+
+	pages := make([]Posts, 0, (len(posts)+perPage-1)/perPage)
+	for start := 0; start < len(posts); start += perPage {
+		end := start + perPage
+		if end > len(posts) {
+			end = len(posts)
+		}
+		pages = append(pages, posts[start:end])
+	}
+
+	return pages
+}
+
+// pagination returns the pagination template data for a page of the post list.
+func pagination(pageNum, totalPages int) paginationData {
+	data := paginationData{
+		CurrentPage:  pageNum,
+		TotalPages:   totalPages,
+		PostsPerPage: config.Values.PostsPerPage,
+	}
+
+	if pageNum > 1 {
+		data.PrevUrl = pageUrl(pageNum - 1)
+	}
+	if pageNum < totalPages {
+		data.NextUrl = pageUrl(pageNum + 1)
+	}
+
+	return data
+}
+
+// pageUrl returns the URL path of a page of the post list.
+func pageUrl(pageNum int) string {
+	if pageNum == 1 {
+		return config.Values.BaseUrlPath + "/"
+	}
+
+	return config.Values.BaseUrlPath + "/" + listPageDirName + "/" + strconv.Itoa(pageNum)
 }
 
 // renderTemplate renders a template and saves the output to a file.
@@ -136,13 +311,29 @@ func renderTemplate(templateName, outputFilename string, tmplData interface{}) e
 
 // postToPostData generates a postData object from a post.
 func postToPostData(post *Post, previewContent bool) postData {
+	tagLinks := make([]tagData, len(post.metadata.tags))
+	for i, tag := range post.metadata.tags {
+		tagLinks[i] = tagData{
+			Name: tag,
+			Url:  tagUrl(tag),
+		}
+	}
+
 	return postData{
 		Title:       post.title,
 		Content:     template.HTML(post.content),
 		Preview:     template.HTML(post.preview),
 		PublishDate: post.metadata.publishDate.Format("2 Jan 2006"),
+		PublishTime: post.metadata.publishDate,
+		UpdateTime:  post.metadata.updateDate,
 		Url:         post.urlPath,
 		Tags:        post.metadata.tags,
+		TagLinks:    tagLinks,
+		Draft:       post.metadata.draft,
+		Params:      post.metadata.params,
+		SourcePath:  post.sourcePath,
+		TOC:         post.toc,
+		TOCHTML:     tocHTML(post.toc),
 	}
 }
 
@@ -154,5 +345,7 @@ func comData() commonData {
 		BlogDescription: config.Values.BlogDescription,
 		CurrentYear:     time.Now().Format("2006"),
 		PageTitle:       config.Values.BlogName,
+		Pages:           pageLinks(),
+		HighlightCssUrl: highlightCSSUrl(),
 	}
 }
@@ -0,0 +1,81 @@
+package posts
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cswilson90/tribo/internal/config"
+)
+
+func TestDefaultTemplates(t *testing.T) {
+	config.Init([]string{})
+	log.SetLevel(log.FatalLevel)
+	config.Values.StaticDir = staticDir
+
+	// Only provide a header so everything else uses the default templates
+	config.Values.TemplateDir = t.TempDir()
+	includesDir := filepath.Join(config.Values.TemplateDir, "includes")
+	os.MkdirAll(includesDir, 0775)
+	customHeader := `<html><body><div id="custom-header">{{.Common.BlogName}}</div>`
+	err := ioutil.WriteFile(filepath.Join(includesDir, "header.html.tmpl"), []byte(customHeader), 0664)
+	if err != nil {
+		t.Fatalf("Failed to write header template: %v", err.Error())
+	}
+
+	tmpDir := t.TempDir()
+	err = BuildPosts(inputDir, tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to build posts: %v", err.Error())
+	}
+
+	assert := assert.New(t)
+	for _, file := range []string{"index.html", "2021/01/2021-01-post-1/index.html"} {
+		html, err := ioutil.ReadFile(filepath.Join(tmpDir, file))
+		if err != nil {
+			t.Errorf("Expected html file '%v' doesn't exist", file)
+			continue
+		}
+
+		assert.Contains(string(html), `<div id="custom-header">`, "Custom header not used in '%v'", file)
+		assert.Contains(string(html), `<div id="blog-footer">`, "Default footer not used in '%v'", file)
+	}
+
+	// Default templates are used when there isn't a template directory
+	config.Values.TemplateDir = filepath.Join(t.TempDir(), "missing")
+	err = BuildPosts(inputDir, t.TempDir())
+	assert.NoError(err, "Failed to build posts without a template directory")
+}
+
+func TestPostToPostData(t *testing.T) {
+	config.Init([]string{})
+	log.SetLevel(log.FatalLevel)
+
+	var post *Post
+	for _, p := range findPosts(inputDir) {
+		if p.sourcePath == "2021/01/post1" {
+			post = p
+		}
+	}
+	if post == nil {
+		t.Fatalf("Failed to find post with source path '2021/01/post1'")
+	}
+
+	_, err := post.readContent()
+	if err != nil {
+		t.Fatalf("Failed to read post: %v", err.Error())
+	}
+
+	data := postToPostData(post, false)
+
+	assert := assert.New(t)
+	assert.Equal(time.Date(2021, time.January, 24, 0, 0, 0, 0, time.UTC), data.PublishTime, "Incorrect publish time")
+	assert.Equal(time.Date(2021, time.February, 1, 0, 0, 0, 0, time.UTC), data.UpdateTime, "Incorrect update time")
+	assert.Equal("cat.jpg", data.Params["hero_image"], "Incorrect custom param")
+	assert.Equal("2021/01/post1", data.SourcePath, "Incorrect source path")
+}
@@ -0,0 +1,57 @@
+package posts
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cswilson90/tribo/internal/config"
+)
+
+const (
+	// themeTemplateDirName is the name of the directory in a theme which contains templates.
+	themeTemplateDirName = "templates"
+	// themeStaticDirName is the name of the directory in a theme which contains static files.
+	themeStaticDirName = "static"
+)
+
+// checkTheme returns an error if a theme has been configured but the theme directory doesn't exist.
+func checkTheme() error {
+	if config.Values.Theme == "" {
+		return nil
+	}
+
+	info, err := os.Stat(config.Values.Theme)
+	if err != nil {
+		return fmt.Errorf("Failed to find theme '%v': "+err.Error(), config.Values.Theme)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("Theme '%v' isn't a directory", config.Values.Theme)
+	}
+
+	return nil
+}
+
+// templateDirs returns the directories templates are loaded from in the order they're layered.
+// The templates directory of the theme comes first, if a theme is set, followed by the
+// blog's template directory. Templates in later directories override those with the same
+// name in earlier directories.
+func templateDirs() []string {
+	if config.Values.Theme == "" {
+		return []string{config.Values.TemplateDir}
+	}
+
+	return []string{filepath.Join(config.Values.Theme, themeTemplateDirName), config.Values.TemplateDir}
+}
+
+// staticDirs returns the directories static files are copied from in the order they're layered.
+// The static directory of the theme comes first, if a theme is set, followed by the blog's
+// static directory. Files in later directories overwrite those with the same name in earlier
+// directories.
+func staticDirs() []string {
+	if config.Values.Theme == "" {
+		return []string{config.Values.StaticDir}
+	}
+
+	return []string{filepath.Join(config.Values.Theme, themeStaticDirName), config.Values.StaticDir}
+}
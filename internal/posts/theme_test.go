@@ -0,0 +1,79 @@
+package posts
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cswilson90/tribo/internal/config"
+)
+
+// writeTestFiles writes files with the given contents to a directory.
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		file := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(file), 0775)
+		err := ioutil.WriteFile(file, []byte(content), 0664)
+		if err != nil {
+			t.Fatalf("Failed to write test file '%v': %v", file, err.Error())
+		}
+	}
+}
+
+func TestTheme(t *testing.T) {
+	config.Init([]string{})
+	log.SetLevel(log.FatalLevel)
+
+	blogDir := t.TempDir()
+	config.Values.Theme = filepath.Join(blogDir, "theme")
+	config.Values.TemplateDir = filepath.Join(blogDir, "templates")
+	config.Values.StaticDir = filepath.Join(blogDir, "static")
+
+	writeTestFiles(t, blogDir, map[string]string{
+		"theme/templates/includes/header.html.tmpl": `<html><body><div id="theme-header"></div>`,
+		"theme/templates/post.html.tmpl":            `{{template "header.html.tmpl" .}}<div id="theme-post"></div>{{template "footer.html.tmpl" .}}`,
+		"theme/static/theme.css":                    "theme",
+		"theme/static/shared.css":                   "theme",
+		"templates/post.html.tmpl":                  `{{template "header.html.tmpl" .}}<div id="site-post"></div>{{template "footer.html.tmpl" .}}`,
+		"static/shared.css":                         "site",
+	})
+
+	tmpDir := t.TempDir()
+	err := BuildPosts(inputDir, tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to build posts: %v", err.Error())
+	}
+
+	assert := assert.New(t)
+
+	postHTML, err := ioutil.ReadFile(filepath.Join(tmpDir, "2021/01/2021-01-post-1/index.html"))
+	if err != nil {
+		t.Fatalf("Failed to read post html: %v", err.Error())
+	}
+	assert.Contains(string(postHTML), `<div id="theme-header">`, "Theme header not used")
+	assert.Contains(string(postHTML), `<div id="site-post">`, "Blog post template not used")
+	assert.NotContains(string(postHTML), `<div id="theme-post">`, "Theme post template not overridden")
+	assert.Contains(string(postHTML), `<div id="blog-footer">`, "Default footer not used")
+
+	indexHTML, err := ioutil.ReadFile(filepath.Join(tmpDir, "index.html"))
+	if err != nil {
+		t.Fatalf("Failed to read index html: %v", err.Error())
+	}
+	assert.Contains(string(indexHTML), `<div id="theme-header">`, "Theme header not used in post list")
+
+	themeCSS, err := ioutil.ReadFile(filepath.Join(tmpDir, "theme.css"))
+	assert.NoError(err, "Theme static file not copied")
+	assert.Equal("theme", string(themeCSS), "Incorrect theme static file")
+
+	sharedCSS, err := ioutil.ReadFile(filepath.Join(tmpDir, "shared.css"))
+	assert.NoError(err, "Static file not copied")
+	assert.Equal("site", string(sharedCSS), "Theme static file not overridden")
+
+	config.Values.Theme = filepath.Join(blogDir, "missing")
+	err = BuildPosts(inputDir, t.TempDir())
+	assert.Error(err, "Expected error building with a missing theme")
+}
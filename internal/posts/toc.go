@@ -0,0 +1,159 @@
+package posts
+
+import (
+	"html"
+	"html/template"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+)
+
+// tocEntry is a heading in the table of contents of a post.
+type tocEntry struct {
+	Title string
+	// ID is the id of the heading in the post's HTML so it can be linked to with "#<ID>".
+	ID    string
+	Level int
+	// Children are the headings in the section below this heading with a higher level.
+	Children []tocEntry
+}
+
+var (
+	// headingIDInvalid matches the characters which aren't allowed in heading ids
+	headingIDInvalid = regexp.MustCompile(`[^a-z0-9_-]`)
+	// headingIDDashes matches the runs of dashes left after removing invalid characters
+	headingIDDashes = regexp.MustCompile(`-{2,}`)
+)
+
+// addHeadingIDs gives every heading after the title of a post an id so it can be linked to.
+// The id is made from the text of the heading in the same way as tag names but only keeping
+// characters which are safe to use in HTML attributes and URL fragments. Headings which
+// already have an id, given with the {#id} syntax, keep it after the same characters are removed.
+// A number is added to the end of an id if it's the same as an earlier heading's.
+func addHeadingIDs(doc ast.Node) {
+	usedIDs := make(map[string]bool)
+	seenTitle := false
+
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		heading, isHeading := node.(*ast.Heading)
+		if !isHeading || !entering {
+			return ast.GoToNext
+		}
+
+		// The first heading is the title which isn't part of the content
+		if !seenTitle {
+			seenTitle = true
+			return ast.SkipChildren
+		}
+
+		id := heading.HeadingID
+		if id == "" {
+			id = headingText(heading)
+		}
+		id = headingID(id)
+		if id == "" {
+			id = "section"
+		}
+
+		uniqueID := id
+		for i := 1; usedIDs[uniqueID]; i++ {
+			uniqueID = id + "-" + strconv.Itoa(i)
+		}
+		usedIDs[uniqueID] = true
+		heading.HeadingID = uniqueID
+
+		return ast.SkipChildren
+	})
+}
+
+// headingID makes a heading id from a string. Only lowercase letters, digits, "_" and "-"
+// are kept so the id doesn't need escaping when used in HTML or links.
+func headingID(s string) string {
+	id := headingIDInvalid.ReplaceAllString(slugify(s), "")
+	return strings.Trim(headingIDDashes.ReplaceAllString(id, "-"), "-")
+}
+
+// tableOfContents returns the headings of a post after the title nested by their level.
+// addHeadingIDs should be called on the document first.
+func tableOfContents(doc ast.Node) []tocEntry {
+	root := &tocEntry{}
+	// stack contains the current heading at each level of the table of contents
+	stack := []*tocEntry{root}
+	seenTitle := false
+
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		heading, isHeading := node.(*ast.Heading)
+		if !isHeading || !entering {
+			return ast.GoToNext
+		}
+
+		if !seenTitle {
+			seenTitle = true
+			return ast.SkipChildren
+		}
+
+		for len(stack) > 1 && stack[len(stack)-1].Level >= heading.Level {
+			stack = stack[:len(stack)-1]
+		}
+
+		parent := stack[len(stack)-1]
+		parent.Children = append(parent.Children, tocEntry{
+			Title: headingText(heading),
+			ID:    heading.HeadingID,
+			Level: heading.Level,
+		})
+		stack = append(stack, &parent.Children[len(parent.Children)-1])
+
+		return ast.SkipChildren
+	})
+
+	return root.Children
+}
+
+// headingText returns the text of a heading without any formatting.
+func headingText(heading *ast.Heading) string {
+	var text strings.Builder
+	ast.WalkFunc(heading, func(node ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.GoToNext
+		}
+
+		switch n := node.(type) {
+		case *ast.Text:
+			text.Write(n.Literal)
+		case *ast.Code:
+			text.Write(n.Literal)
+		}
+		return ast.GoToNext
+	})
+
+	return strings.TrimSpace(text.String())
+}
+
+// tocHTML renders a table of contents as nested HTML lists of links to the headings.
+func tocHTML(entries []tocEntry) template.HTML {
+	if len(entries) == 0 {
+		return ""
+	}
+
+	var output strings.Builder
+	writeTOCList(&output, entries)
+
+	return template.HTML(output.String())
+}
+
+// writeTOCList writes a list of table of contents entries, and their children, as HTML.
+func writeTOCList(output *strings.Builder, entries []tocEntry) {
+	output.WriteString("<ul>\n")
+	for _, entry := range entries {
+		output.WriteString(`<li><a href="#` + html.EscapeString(entry.ID) + `">` + html.EscapeString(entry.Title) + "</a>")
+		if len(entry.Children) > 0 {
+			output.WriteString("\n")
+			writeTOCList(output, entry.Children)
+		}
+		output.WriteString("</li>\n")
+	}
+	output.WriteString("</ul>\n")
+}
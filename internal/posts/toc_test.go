@@ -0,0 +1,88 @@
+package posts
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cswilson90/tribo/internal/config"
+)
+
+const tocMarkdown = `# Design Doc
+
+Introduction.
+
+## Background
+
+### Current System
+
+### Problems
+
+## Proposal {#the-plan}
+
+### Problems
+
+## Rollout ` + "`v2`" + `
+
+## Using "quotes" & Tom's more
+`
+
+func TestTableOfContents(t *testing.T) {
+	config.Init([]string{})
+	assert := assert.New(t)
+
+	doc := parseMarkdown([]byte(tocMarkdown), config.Values.Markdown)
+	toc := tableOfContents(doc)
+
+	expected := []tocEntry{
+		{Title: "Background", ID: "background", Level: 2, Children: []tocEntry{
+			{Title: "Current System", ID: "current-system", Level: 3},
+			{Title: "Problems", ID: "problems", Level: 3},
+		}},
+		{Title: "Proposal", ID: "the-plan", Level: 2, Children: []tocEntry{
+			{Title: "Problems", ID: "problems-1", Level: 3},
+		}},
+		{Title: "Rollout v2", ID: "rollout-v2", Level: 2},
+		{Title: `Using "quotes" & Tom's more`, ID: "using-quotes-toms-more", Level: 2},
+	}
+	assert.Equal(expected, toc, "Incorrect table of contents")
+
+	content := renderMarkdown(doc, renderPost, config.Values.Markdown)
+	assert.Contains(content, `<h3 id="problems-1">Problems</h3>`, "Duplicate heading not given a unique id")
+	assert.Contains(content, `<h2 id="the-plan">Proposal</h2>`, "Explicit heading id not kept")
+	assert.NotContains(content, "heading-anchor", "Heading anchors added when not enabled")
+
+	html := string(tocHTML(toc))
+	assert.Contains(html, `<li><a href="#background">Background</a>`+"\n<ul>\n"+`<li><a href="#current-system">Current System</a></li>`,
+		"Incorrect nesting in table of contents HTML")
+	assert.Contains(html, `<li><a href="#rollout-v2">Rollout v2</a></li>`, "Missing entry in table of contents HTML")
+	assert.Contains(html, `<li><a href="#using-quotes-toms-more">`, "Unsafe characters not removed from heading id")
+	assert.Empty(tocHTML(nil), "Table of contents HTML generated without entries")
+
+	config.Values.HeadingAnchors = true
+	content = renderMarkdown(doc, renderPost, config.Values.Markdown)
+	assert.Contains(content, `<h2 id="background">Background<a class="heading-anchor" href="#background">#</a></h2>`,
+		"Heading anchor not added")
+	assert.Contains(content, `<h2 id="using-quotes-toms-more">`, "Unsafe characters not removed from heading id")
+	assert.Contains(content, `<a class="heading-anchor" href="#using-quotes-toms-more">#</a>`,
+		"Unsafe characters not removed from heading anchor")
+}
+
+func TestTocEnabled(t *testing.T) {
+	config.Init([]string{})
+	assert := assert.New(t)
+
+	enabled, disabled := true, false
+	post := &Post{metadata: &PostMetadata{}}
+	assert.False(post.tocEnabled(), "Table of contents enabled by default")
+
+	post.metadata.toc = &enabled
+	assert.True(post.tocEnabled(), "Metadata didn't enable table of contents")
+
+	config.Values.Toc = true
+	post.metadata.toc = nil
+	assert.True(post.tocEnabled(), "Config didn't enable table of contents")
+
+	post.metadata.toc = &disabled
+	assert.False(post.tocEnabled(), "Metadata didn't disable table of contents")
+}
@@ -0,0 +1,376 @@
+/*
+	Package serve runs a development server for previewing a blog.
+
+	The blog is built into a temporary directory and served over HTTP. The posts,
+	static, template directories and the config file are watched and the blog is
+	rebuilt whenever they change. Open pages are reloaded after each rebuild using
+	a script injected into every HTML page which listens for server-sent events.
+
+		err := serve.Run("localhost:8080", func() {
+			config.Init(os.Args[2:])
+		})
+*/
+package serve
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/cswilson90/tribo/internal/config"
+	"github.com/cswilson90/tribo/internal/posts"
+)
+
+const (
+	// reloadPath is the URL path of the server-sent events endpoint used for live reload.
+	reloadPath = "/_tribo/reload"
+
+	// rebuildDelay is how long to wait after a file changes before rebuilding.
+	// Editors often write several files at once so this stops a rebuild for each one.
+	rebuildDelay = 200 * time.Millisecond
+)
+
+// reloadScript is injected into every HTML page to reload it after a rebuild.
+var reloadScript = []byte(`<script>
+new EventSource("` + reloadPath + `").addEventListener("reload", function () {
+    location.reload();
+});
+</script>
+`)
+
+// server serves a blog built into a temporary directory.
+type server struct {
+	addr      string
+	outputDir string
+	// loadConfig populates config.Values, it's called before every build.
+	loadConfig func() error
+
+	// buildLock is held for writing while the blog is being built so pages
+	// aren't served half way through a build.
+	buildLock   sync.RWMutex
+	baseUrlPath string
+
+	clientsLock sync.Mutex
+	clients     map[chan struct{}]bool
+
+	watcher *fsnotify.Watcher
+	watched map[string]bool
+}
+
+/*
+	Run builds the blog and serves it on addr until the program is stopped.
+
+	loadConfig should populate config.Values and is called before every build so
+	changes to the config file are picked up. If it returns an error the last config
+	loaded is used for the build. The output directory set in the config is ignored
+	and the blog is built into a temporary directory instead.
+*/
+func Run(addr string, loadConfig func() error) error {
+	outputDir, err := ioutil.TempDir("", "tribo-serve-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(outputDir)
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	s := &server{
+		addr:       addr,
+		outputDir:  outputDir,
+		loadConfig: loadConfig,
+		clients:    make(map[chan struct{}]bool),
+		watcher:    watcher,
+		watched:    make(map[string]bool),
+	}
+
+	s.rebuild()
+	go s.watch()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(reloadPath, s.serveReloadEvents)
+	mux.HandleFunc("/", s.serveBlog)
+
+	// Stop serving on interrupt so the temporary directory is cleaned up
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- http.ListenAndServe(addr, mux)
+	}()
+
+	log.Infof("Serving blog at http://%v%v/", addr, s.baseUrlPath)
+	select {
+	case err = <-serveErr:
+		return err
+	case <-stop:
+		log.Infof("Stopping server")
+		return nil
+	}
+}
+
+// rebuild loads the config and builds the blog into the output directory.
+// Once the build has finished any open pages are told to reload.
+func (s *server) rebuild() {
+	s.buildLock.Lock()
+
+	err := s.loadConfig()
+	if err != nil {
+		log.Errorf("Failed to load config, using the last config loaded: " + err.Error())
+	}
+	config.Values.OutputDir = s.outputDir
+	config.Values.RssLinkUrl = "http://" + s.addr
+	s.baseUrlPath = strings.TrimSuffix(config.Values.BaseUrlPath, "/")
+
+	start := time.Now()
+	err = posts.BuildPosts(config.Values.PostsDir, s.outputDir)
+	if err != nil {
+		log.Errorf("Failed to build blog: " + err.Error())
+	} else {
+		log.Infof("Built blog in %v", time.Since(start).Round(time.Millisecond))
+	}
+
+	s.updateWatches()
+	s.buildLock.Unlock()
+
+	s.notifyClients()
+}
+
+// watch rebuilds the blog whenever a watched file changes.
+func (s *server) watch() {
+	// rebuildTimer delays rebuilding until rebuildDelay after the last change
+	rebuildTimer := time.NewTimer(rebuildDelay)
+	rebuildTimer.Stop()
+
+	for {
+		select {
+		case event, ok := <-s.watcher.Events:
+			if !ok {
+				return
+			}
+			if !s.isWatchedEvent(event) {
+				continue
+			}
+			log.Debugf("Detected change to '%v'", event.Name)
+			rebuildTimer.Reset(rebuildDelay)
+		case err, ok := <-s.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Warnf("Error watching files: " + err.Error())
+		case <-rebuildTimer.C:
+			log.Infof("Files changed, rebuilding blog")
+			s.rebuild()
+		}
+	}
+}
+
+// isWatchedEvent returns true if a file change should trigger a rebuild.
+func (s *server) isWatchedEvent(event fsnotify.Event) bool {
+	if event.Op == fsnotify.Chmod {
+		return false
+	}
+
+	// The directory containing the config file is watched so only changes to the
+	// config file itself should trigger a rebuild from that directory
+	s.buildLock.RLock()
+	defer s.buildLock.RUnlock()
+
+	dir := filepath.Dir(event.Name)
+	if dir == filepath.Dir(config.ConfigFile) && !s.isInputPath(event.Name) {
+		return event.Name == config.ConfigFile
+	}
+
+	return true
+}
+
+// isInputPath returns true if a path is inside one of the blog's input directories.
+func (s *server) isInputPath(file string) bool {
+	for _, dir := range s.inputDirs() {
+		if file == dir || strings.HasPrefix(file, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// inputDirs returns the directories the blog is built from.
+func (s *server) inputDirs() []string {
+	dirs := []string{config.Values.PostsDir, config.Values.PagesDir, config.Values.StaticDir, config.Values.TemplateDir}
+	if config.Values.Theme != "" {
+		dirs = append(dirs, config.Values.Theme)
+	}
+
+	return dirs
+}
+
+// updateWatches makes sure all the input directories and the config file are being watched.
+// It should be called after every build as the config or directory structure may have changed.
+func (s *server) updateWatches() {
+	wanted := make(map[string]bool)
+	wanted[filepath.Dir(config.ConfigFile)] = true
+
+	for _, inputDir := range s.inputDirs() {
+		// fsnotify doesn't watch recursively so every sub-directory needs adding
+		filepath.Walk(inputDir, func(file string, info os.FileInfo, err error) error {
+			if err == nil && info.IsDir() {
+				wanted[file] = true
+			}
+			return nil
+		})
+	}
+
+	for dir := range s.watched {
+		if !wanted[dir] {
+			s.watcher.Remove(dir)
+			delete(s.watched, dir)
+		}
+	}
+
+	for dir := range wanted {
+		if s.watched[dir] {
+			continue
+		}
+		err := s.watcher.Add(dir)
+		if err != nil {
+			log.Warnf("Failed to watch '%v': "+err.Error(), dir)
+			continue
+		}
+		s.watched[dir] = true
+	}
+}
+
+// serveBlog serves files from the built blog, injecting the reload script into HTML pages.
+func (s *server) serveBlog(w http.ResponseWriter, r *http.Request) {
+	s.buildLock.RLock()
+	defer s.buildLock.RUnlock()
+
+	urlPath := path.Clean("/" + r.URL.Path)
+	if s.baseUrlPath != "" {
+		if urlPath == "/" {
+			http.Redirect(w, r, s.baseUrlPath+"/", http.StatusFound)
+			return
+		}
+		if urlPath != s.baseUrlPath && !strings.HasPrefix(urlPath, s.baseUrlPath+"/") {
+			http.NotFound(w, r)
+			return
+		}
+		urlPath = "/" + strings.TrimPrefix(strings.TrimPrefix(urlPath, s.baseUrlPath), "/")
+	}
+
+	file := filepath.Join(s.outputDir, filepath.FromSlash(urlPath))
+	info, err := os.Stat(file)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	if info.IsDir() {
+		// Redirect to the directory with a trailing slash so relative links work
+		if !strings.HasSuffix(r.URL.Path, "/") {
+			http.Redirect(w, r, r.URL.Path+"/", http.StatusMovedPermanently)
+			return
+		}
+		file = filepath.Join(file, "index.html")
+		info, err = os.Stat(file)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+	}
+
+	if filepath.Ext(file) != ".html" {
+		http.ServeFile(w, r, file)
+		return
+	}
+
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	http.ServeContent(w, r, file, info.ModTime(), bytes.NewReader(injectReloadScript(content)))
+}
+
+// injectReloadScript adds the live reload script to a HTML page.
+// The script is added before the closing body tag or at the end of the page if there isn't one.
+func injectReloadScript(page []byte) []byte {
+	bodyEnd := bytes.LastIndex(bytes.ToLower(page), []byte("</body>"))
+	if bodyEnd < 0 {
+		return append(page, reloadScript...)
+	}
+
+	injected := make([]byte, 0, len(page)+len(reloadScript))
+	injected = append(injected, page[:bodyEnd]...)
+	injected = append(injected, reloadScript...)
+	injected = append(injected, page[bodyEnd:]...)
+	return injected
+}
+
+// serveReloadEvents streams server-sent events to a page, sending a reload event after each rebuild.
+func (s *server) serveReloadEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	reload := make(chan struct{}, 1)
+	s.clientsLock.Lock()
+	s.clients[reload] = true
+	s.clientsLock.Unlock()
+
+	defer func() {
+		s.clientsLock.Lock()
+		delete(s.clients, reload)
+		s.clientsLock.Unlock()
+	}()
+
+	for {
+		select {
+		case <-reload:
+			fmt.Fprint(w, "event: reload\ndata: {}\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// notifyClients tells all open pages to reload.
+func (s *server) notifyClients() {
+	s.clientsLock.Lock()
+	defer s.clientsLock.Unlock()
+
+	for client := range s.clients {
+		// Don't block if the client already has a reload pending
+		select {
+		case client <- struct{}{}:
+		default:
+		}
+	}
+}
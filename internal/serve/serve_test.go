@@ -0,0 +1,79 @@
+package serve
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var injectTests = []struct {
+	page     string
+	expected string
+}{
+	{
+		page:     "<html><body><p>Post</p></body></html>",
+		expected: "<html><body><p>Post</p>" + string(reloadScript) + "</body></html>",
+	},
+	{
+		page:     "<html><BODY><p>Post</p></BODY></html>",
+		expected: "<html><BODY><p>Post</p>" + string(reloadScript) + "</BODY></html>",
+	},
+	{
+		page:     "<p>No body</p>",
+		expected: "<p>No body</p>" + string(reloadScript),
+	},
+}
+
+func TestInjectReloadScript(t *testing.T) {
+	for i, tc := range injectTests {
+		assert.Equal(t, tc.expected, string(injectReloadScript([]byte(tc.page))), "Test %v incorrect page", i+1)
+	}
+}
+
+var serveTests = []struct {
+	path           string
+	expectedStatus int
+	expectedBody   string
+}{
+	{"/", http.StatusFound, ""},
+	{"/blog/", http.StatusOK, "<body>Index" + string(reloadScript) + "</body>"},
+	{"/blog/2021/01/post", http.StatusMovedPermanently, ""},
+	{"/blog/2021/01/post/", http.StatusOK, "<body>Post" + string(reloadScript) + "</body>"},
+	{"/blog/test.css", http.StatusOK, "body {}"},
+	{"/blog/missing/", http.StatusNotFound, ""},
+	{"/other/", http.StatusNotFound, ""},
+}
+
+func TestServeBlog(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"index.html":              "<body>Index</body>",
+		"2021/01/post/index.html": "<body>Post</body>",
+		"test.css":                "body {}",
+	}
+	for file, content := range files {
+		fullPath := filepath.Join(tmpDir, file)
+		os.MkdirAll(filepath.Dir(fullPath), 0775)
+		ioutil.WriteFile(fullPath, []byte(content), 0664)
+	}
+
+	s := &server{
+		outputDir:   tmpDir,
+		baseUrlPath: "/blog",
+	}
+
+	for _, tc := range serveTests {
+		recorder := httptest.NewRecorder()
+		s.serveBlog(recorder, httptest.NewRequest("GET", tc.path, nil))
+
+		assert.Equal(t, tc.expectedStatus, recorder.Code, "Incorrect status for '%v'", tc.path)
+		if tc.expectedBody != "" {
+			assert.Equal(t, tc.expectedBody, recorder.Body.String(), "Incorrect body for '%v'", tc.path)
+		}
+	}
+}
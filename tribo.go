@@ -1,13 +1,87 @@
 package tribo
 
 import (
+	"flag"
 	"os"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
 
 	"github.com/cswilson90/tribo/internal/config"
 	"github.com/cswilson90/tribo/internal/posts"
+	"github.com/cswilson90/tribo/internal/serve"
 )
 
+// RunTribo runs Tribo using the command line arguments.
+// By default the blog is built once. If the first argument is a sub-command
+// such as "serve" that command is run instead.
 func RunTribo() {
-	config.Init(os.Args[1:])
-	posts.BuildPosts(config.Values.PostsDir, config.Values.OutputDir)
+	args := os.Args[1:]
+	if len(args) > 0 {
+		switch args[0] {
+		case "serve":
+			runServe(args[1:])
+			return
+		case "new":
+			runNew(args[1:])
+			return
+		case "init":
+			runInit(args[1:])
+			return
+		}
+	}
+
+	config.Init(args)
+	err := posts.BuildPosts(config.Values.PostsDir, config.Values.OutputDir)
+	if err != nil {
+		log.Fatalf(err.Error())
+	}
+}
+
+// runServe runs the development server.
+func runServe(args []string) {
+	var addr *string
+	loadConfig := func() error {
+		flags := flag.NewFlagSet(os.Args[0]+" serve", flag.ExitOnError)
+		addr = flags.String("addr", "localhost:8080", "address to serve the blog on")
+		return config.LoadFlags(flags, args)
+	}
+
+	// Load the config once to get the address to serve on
+	err := loadConfig()
+	if err != nil {
+		log.Fatalf(err.Error())
+	}
+
+	err = serve.Run(*addr, loadConfig)
+	if err != nil {
+		log.Fatalf(err.Error())
+	}
+}
+
+// runNew creates the directory for a new post.
+// The title of the post is given by the arguments left after the flags.
+func runNew(args []string) {
+	flags := flag.NewFlagSet(os.Args[0]+" new", flag.ExitOnError)
+	tagList := flags.String("tags", "", "comma separated list of tags for the post")
+	config.InitFlags(flags, args)
+
+	title := strings.Join(flags.Args(), " ")
+	if title == "" {
+		log.Fatalf("Usage: %v new [options] <title>", os.Args[0])
+	}
+
+	tags := make([]string, 0)
+	for _, tag := range strings.Split(*tagList, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	_, err := posts.NewPost(config.Values.PostsDir, title, tags, time.Now())
+	if err != nil {
+		log.Fatalf("Failed to create new post: " + err.Error())
+	}
 }